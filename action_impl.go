@@ -0,0 +1,92 @@
+package libonebot
+
+import (
+	"reflect"
+)
+
+// Implementation 表示实现了 OneBot 标准定义的所有动作的对象.
+//
+// OneBot 实现通常只需实现其中的一部分 (即 UserImpl, GroupImpl 等接口或 SendMessageImpl 等单个动作的接口),
+// 然后通过 ActionMux.HandleImplementation 注册, 未实现的动作不会被注册.
+type Implementation interface {
+	UserImpl
+	GroupImpl
+	GuildImpl
+	MessageImpl
+	FileImpl
+	MetaImpl
+}
+
+var implActions = []struct {
+	action   string
+	implType reflect.Type
+}{
+	{ActionGetSelfInfo, reflect.TypeOf((*GetSelfInfoImpl)(nil)).Elem()},
+	{ActionGetUserInfo, reflect.TypeOf((*GetUserInfoImpl)(nil)).Elem()},
+	{ActionGetFriendList, reflect.TypeOf((*GetFriendListImpl)(nil)).Elem()},
+	{ActionGetGroupInfo, reflect.TypeOf((*GetGroupInfoImpl)(nil)).Elem()},
+	{ActionGetGroupList, reflect.TypeOf((*GetGroupListImpl)(nil)).Elem()},
+	{ActionGetGroupMemberInfo, reflect.TypeOf((*GetGroupMemberInfoImpl)(nil)).Elem()},
+	{ActionGetGroupMemberList, reflect.TypeOf((*GetGroupMemberListImpl)(nil)).Elem()},
+	{ActionSetGroupName, reflect.TypeOf((*SetGroupNameImpl)(nil)).Elem()},
+	{ActionLeaveGroup, reflect.TypeOf((*LeaveGroupImpl)(nil)).Elem()},
+	{ActionGetGuildInfo, reflect.TypeOf((*GetGuildInfoImpl)(nil)).Elem()},
+	{ActionGetGuildList, reflect.TypeOf((*GetGuildListImpl)(nil)).Elem()},
+	{ActionSetGuildName, reflect.TypeOf((*SetGuildNameImpl)(nil)).Elem()},
+	{ActionGetGuildMemberInfo, reflect.TypeOf((*GetGuildMemberInfoImpl)(nil)).Elem()},
+	{ActionGetGuildMemberList, reflect.TypeOf((*GetGuildMemberListImpl)(nil)).Elem()},
+	{ActionLeaveGuild, reflect.TypeOf((*LeaveGuildImpl)(nil)).Elem()},
+	{ActionGetChannelInfo, reflect.TypeOf((*GetChannelInfoImpl)(nil)).Elem()},
+	{ActionGetChannelList, reflect.TypeOf((*GetChannelListImpl)(nil)).Elem()},
+	{ActionSetChannelName, reflect.TypeOf((*SetChannelNameImpl)(nil)).Elem()},
+	{ActionGetChannelMemberInfo, reflect.TypeOf((*GetChannelMemberInfoImpl)(nil)).Elem()},
+	{ActionGetChannelMemberList, reflect.TypeOf((*GetChannelMemberListImpl)(nil)).Elem()},
+	{ActionLeaveChannel, reflect.TypeOf((*LeaveChannelImpl)(nil)).Elem()},
+	{ActionSendMessage, reflect.TypeOf((*SendMessageImpl)(nil)).Elem()},
+	{ActionDeleteMessage, reflect.TypeOf((*DeleteMessageImpl)(nil)).Elem()},
+	{ActionUploadFile, reflect.TypeOf((*UploadFileImpl)(nil)).Elem()},
+	{ActionUploadFileFragmented, reflect.TypeOf((*UploadFileFragmentedImpl)(nil)).Elem()},
+	{ActionGetFile, reflect.TypeOf((*GetFileImpl)(nil)).Elem()},
+	{ActionGetFileFragmented, reflect.TypeOf((*GetFileFragmentedImpl)(nil)).Elem()},
+	{ActionGetStatus, reflect.TypeOf((*GetStatusImpl)(nil)).Elem()},
+	{ActionGetVersion, reflect.TypeOf((*GetVersionImpl)(nil)).Elem()},
+}
+
+type implHandler struct {
+	method reflect.Value
+}
+
+// HandleAction 为 implHandler 实现 Handler 接口.
+func (h implHandler) HandleAction(w ResponseWriter, r *Request) {
+	params := reflect.New(h.method.Type().In(1))
+	if err := DecodeParams(r.Params, params.Interface()); err != nil {
+		w.WriteError(err)
+		return
+	}
+	for i := 0; i < params.Elem().NumField(); i++ {
+		if params.Elem().Type().Field(i).PkgPath != "" {
+			continue // unexported
+		}
+		if m, ok := params.Elem().Field(i).Interface().(Message); ok {
+			if err := r.ValidateMessage(m); err != nil {
+				w.WriteError(err)
+				return
+			}
+		}
+	}
+
+	results := h.method.Call([]reflect.Value{reflect.ValueOf(r), params.Elem()})
+	if err, _ := results[len(results)-1].Interface().(error); err != nil {
+		w.WriteError(err)
+		return
+	}
+	if len(results) == 1 {
+		w.WriteData(nil)
+		return
+	}
+	data := results[0]
+	if data.Kind() == reflect.Slice && data.IsNil() {
+		data = reflect.MakeSlice(data.Type(), 0, 0) // respond with empty list rather than null
+	}
+	w.WriteData(data.Interface())
+}
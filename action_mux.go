@@ -2,6 +2,7 @@ package libonebot
 
 import (
 	"fmt"
+	"reflect"
 	"sort"
 )
 
@@ -11,11 +12,14 @@ type ActionMux struct {
 }
 
 // NewActionMux 创建一个新的 ActionMux 对象.
+//
+// 新创建的 ActionMux 自动处理 get_supported_actions 和 libonebot.get_supported_segments 动作.
 func NewActionMux() *ActionMux {
 	mux := &ActionMux{
 		handlers: make(map[string]Handler),
 	}
 	mux.HandleFunc(ActionGetSupportedActions, mux.handleGetSupportedActions)
+	mux.HandleFunc(ActionGetSupportedSegments, handleGetSupportedSegments)
 	return mux
 }
 
@@ -30,6 +34,20 @@ func (mux *ActionMux) handleGetSupportedActions(w ResponseWriter, r *Request) {
 	w.WriteData(actions)
 }
 
+// handleGetSupportedSegments 返回处理请求的 OneBot 实例支持的消息段类型, 请求不由 OneBot 实例处理时返回标准消息段类型.
+func handleGetSupportedSegments(w ResponseWriter, r *Request) {
+	if r.segments != nil {
+		w.WriteData(r.segments.SupportedTypes())
+		return
+	}
+	types := make([]string, 0, len(standardSegmentTypes))
+	for _, t := range standardSegmentTypes {
+		types = append(types, t.Name)
+	}
+	sort.Strings(types)
+	w.WriteData(types)
+}
+
 // HandleAction 为 ActionMux 实现 Handler 接口.
 func (mux *ActionMux) HandleAction(w ResponseWriter, r *Request) {
 	// return "ok" if otherwise explicitly set to "failed"
@@ -61,3 +79,22 @@ func (mux *ActionMux) Handle(action string, handler Handler) {
 	}
 	mux.handlers[action] = handler
 }
+
+// HandleImplementation 将一个实现了部分标准动作的对象注册为对应动作的请求处理器.
+//
+// impl 可以实现 Implementation 接口中的任意部分 (如 SendMessageImpl, UserImpl 等),
+// 只有 impl 实际实现了的动作会被注册, 因此 get_supported_actions 动作的响应保持准确.
+// impl 为 nil 时 panic.
+func (mux *ActionMux) HandleImplementation(impl interface{}) {
+	if impl == nil {
+		panic("动作实现对象不能为 nil")
+	}
+	implValue := reflect.ValueOf(impl)
+	for _, a := range implActions {
+		if !implValue.Type().Implements(a.implType) {
+			continue
+		}
+		method := implValue.MethodByName(a.implType.Method(0).Name)
+		mux.Handle(a.action, implHandler{method: method})
+	}
+}
@@ -3,6 +3,7 @@ package libonebot
 import (
 	"encoding/base64"
 	"fmt"
+	"reflect"
 )
 
 // ParamGetter 用于在处理动作请求时方便地获取参数, 当参数不存在或参数错误时,
@@ -10,6 +11,7 @@ import (
 type ParamGetter struct {
 	params EasierMap
 	w      ResponseWriter
+	r      *Request
 }
 
 // NewParamGetter 创建一个 ParamGetter 对象.
@@ -17,6 +19,7 @@ func NewParamGetter(w ResponseWriter, r *Request) *ParamGetter {
 	return &ParamGetter{
 		params: r.Params,
 		w:      w,
+		r:      r,
 	}
 }
 
@@ -83,12 +86,144 @@ func (p *ParamGetter) GetBytesOrBase64(key string) ([]byte, bool) {
 	return b, true
 }
 
-// GetMessage 获取一个消息类型参数.
+// GetMessage 获取一个消息类型参数, 并通过 Request.ValidateMessage 检查消息段,
+// 消息段无效时写入相应的 RetCodeUnsupportedSegment, RetCodeBadSegmentData 或 RetCodeUnsupportedSegmentData 返回码.
 func (p *ParamGetter) GetMessage(key string) (Message, bool) {
 	val, err := p.params.GetMessage(key)
 	if err != nil {
 		p.w.WriteFailed(RetCodeBadParam, errorParam(err))
 		return val, false
 	}
+	if err := p.r.ValidateMessage(val); err != nil {
+		p.w.WriteError(err)
+		return val, false
+	}
 	return val, true
 }
+
+var (
+	messageType        = reflect.TypeOf(Message{})
+	bytesType          = reflect.TypeOf([]byte{})
+	extendedFieldsType = reflect.TypeOf(ExtendedFields{})
+)
+
+// DecodeParams 将动作参数解码到结构体 (如 SendMessageParams) 中.
+//
+// 字段名由 json tag 确定, 没有 omitempty 选项的字段为必需参数;
+// ExtendedFields 类型的字段将收集所有未在结构体中声明的参数 (通常为扩展参数).
+// 解码失败时返回的错误为 RetCodeBadParam 返回码的 ActionError.
+func DecodeParams(params EasierMap, v interface{}) error {
+	if err := decodeParams(params, v); err != nil {
+		return NewActionError(RetCodeBadParam, errorParam(err))
+	}
+	return nil
+}
+
+func decodeParams(params EasierMap, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	known := make(map[string]bool)
+	var extended reflect.Value
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if field.Type == extendedFieldsType {
+			extended = rv.Field(i)
+			continue
+		}
+		name, optional := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+		known[name] = true
+		if _, err := params.Get(name); err != nil {
+			if optional {
+				continue
+			}
+			return err
+		}
+		val, err := getParamValue(params, name, field.Type)
+		if err != nil {
+			return err
+		}
+		rv.Field(i).Set(val)
+	}
+	if extended.IsValid() {
+		ext := make(ExtendedFields)
+		for k, val := range params.Value() {
+			if !known[k] {
+				ext[k] = val
+			}
+		}
+		extended.Set(reflect.ValueOf(ext))
+	}
+	if validator, ok := v.(interface{ validate() error }); ok {
+		return validator.validate()
+	}
+	return nil
+}
+
+func getParamValue(params EasierMap, key string, t reflect.Type) (reflect.Value, error) {
+	var val interface{}
+	var err error
+	switch {
+	case t == messageType:
+		val, err = params.GetMessage(key)
+	case t == bytesType:
+		val, err = params.GetBytes(key)
+		if err != nil {
+			var s string
+			s, err = params.GetString(key)
+			if err == nil {
+				val, err = base64.StdEncoding.DecodeString(s)
+			}
+		}
+	case t.Kind() == reflect.String:
+		val, err = params.GetString(key)
+	case t.Kind() == reflect.Bool:
+		val, err = params.GetBool(key)
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		var n int64
+		if n, err = params.GetInt64(key); err == nil && reflect.Zero(t).OverflowInt(n) {
+			err = fmt.Errorf("`%v` 字段超出取值范围", key)
+		}
+		val = n
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		var n int64
+		if n, err = params.GetInt64(key); err == nil && (n < 0 || reflect.Zero(t).OverflowUint(uint64(n))) {
+			err = fmt.Errorf("`%v` 字段超出取值范围", key)
+		}
+		val = n
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		val, err = params.GetFloat64(key)
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String:
+		var m EasierMap
+		m, err = params.GetMap(key)
+		if err == nil {
+			sm := reflect.MakeMap(t)
+			for k := range m.Value() {
+				var s string
+				if s, err = m.GetString(k); err != nil {
+					break
+				}
+				sm.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(s).Convert(t.Elem()))
+			}
+			val = sm.Interface()
+		}
+	default:
+		val, err = params.Get(key)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() {
+		return reflect.Zero(t), nil
+	}
+	if !rv.Type().ConvertibleTo(t) {
+		return reflect.Value{}, fmt.Errorf("`%v` 字段是无效值", key)
+	}
+	return rv.Convert(t), nil
+}
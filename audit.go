@@ -0,0 +1,190 @@
+// 审计日志
+
+package libonebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord 表示一次动作请求的审计记录.
+type AuditRecord struct {
+	Time       time.Time              `json:"time"`                  // 收到动作请求的时间
+	Comm       string                 `json:"comm"`                  // 接收动作请求的通信方式, OneBot 实现内部构造的请求为 `none`
+	RemoteAddr string                 `json:"remote_addr,omitempty"` // 请求方的网络地址
+	Principal  string                 `json:"principal,omitempty"`   // 通过鉴权的请求方身份, 见 RequestComm.Principal
+	Self       *Self                  `json:"self,omitempty"`        // 动作请求指定的机器人自身标识
+	Action     string                 `json:"action"`                // 动作名称
+	Params     map[string]interface{} `json:"params"`                // 按 OneBot.ParamRedactor 脱敏后的动作参数
+	Echo       string                 `json:"echo,omitempty"`        // 动作请求的 echo 字段
+	Status     string                 `json:"status"`                // 动作响应的执行状态
+	RetCode    int                    `json:"retcode"`               // 动作响应的返回码
+	Message    string                 `json:"message,omitempty"`     // 动作响应的错误信息
+	Latency    time.Duration          `json:"latency_ns"`            // 处理耗时
+}
+
+// AuditSink 接收动作请求的审计记录.
+//
+// WriteAudit 在处理动作请求的 goroutine 中同步调用, 可能被并发调用.
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+// AuditSinkFunc 表示一个实现 AuditSink 接口的函数.
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAudit 为 AuditSinkFunc 实现 AuditSink 接口.
+func (f AuditSinkFunc) WriteAudit(record AuditRecord) error {
+	return f(record)
+}
+
+func (ob *OneBot) audit(r *Request, resp Response, start time.Time) {
+	if ob.AuditSink == nil {
+		return
+	}
+	comm, ok := commMethodLabels[r.Comm.Method]
+	if !ok {
+		comm = "none"
+	}
+	record := AuditRecord{
+		Time:       start,
+		Comm:       comm,
+		RemoteAddr: r.Comm.RemoteAddr,
+		Principal:  r.Comm.Principal,
+		Self:       r.Self,
+		Action:     r.Action,
+		Params:     ob.ParamRedactor.Redact(r.Params.Value()),
+		Echo:       r.Echo,
+		Status:     resp.Status,
+		RetCode:    resp.RetCode,
+		Message:    resp.Message,
+		Latency:    time.Since(start),
+	}
+	if err := ob.AuditSink.WriteAudit(record); err != nil {
+		ob.Logger.Errorf("动作请求 `%v` 审计记录写入失败, 错误: %v", r.Action, err)
+	}
+}
+
+// FileAuditSink 将审计记录以 JSON Lines 格式写入文件, 并在文件达到指定大小时轮转.
+//
+// 轮转时当前文件被重命名为 `<path>.1`, 原有的 `<path>.1` 被重命名为 `<path>.2`, 以此类推,
+// 超出保留数量的旧文件将被删除.
+type FileAuditSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	lock       *sync.Mutex
+}
+
+// NewFileAuditSink 创建一个新的 FileAuditSink 对象, 文件已存在时追加写入.
+//
+// 参数:
+//   path: 审计日志文件路径
+//   maxSize: 单个文件的最大字节数, 0 表示不轮转
+//   maxBackups: 轮转后保留的旧文件数量, 0 表示不保留
+func NewFileAuditSink(path string, maxSize int64, maxBackups int) (*FileAuditSink, error) {
+	s := &FileAuditSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		lock:       &sync.Mutex{},
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileAuditSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("审计日志文件打开失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("审计日志文件打开失败: %v", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// WriteAudit 为 FileAuditSink 实现 AuditSink 接口.
+//
+// 轮转失败时继续写入原来的文件, 并返回轮转错误, 下次写入时重试轮转.
+func (s *FileAuditSink) WriteAudit(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	var rotateErr error
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		rotateErr = s.rotate()
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+func (s *FileAuditSink) backupPath(i int) string {
+	return fmt.Sprintf("%v.%v", s.path, i)
+}
+
+// rotate 轮转审计日志文件, 新文件打开后才关闭原来的文件, 失败时继续使用原来的文件.
+func (s *FileAuditSink) rotate() error {
+	// move the current file aside first, so that nothing is lost if the new file can't be opened
+	rotating := s.path + ".rotating"
+	if err := os.Rename(s.path, rotating); err != nil {
+		return fmt.Errorf("审计日志文件轮转失败: %v", err)
+	}
+	oldFile, oldSize := s.file, s.size
+	if err := s.open(); err != nil {
+		if renameErr := os.Rename(rotating, s.path); renameErr != nil {
+			err = fmt.Errorf("%v, 恢复原文件失败: %v", err, renameErr)
+		}
+		s.file, s.size = oldFile, oldSize
+		return fmt.Errorf("审计日志文件轮转失败: %v", err)
+	}
+	oldFile.Close()
+
+	if s.maxBackups > 0 {
+		os.Remove(s.backupPath(s.maxBackups))
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			os.Rename(s.backupPath(i), s.backupPath(i+1)) // ignore missing backups
+		}
+		if err := os.Rename(rotating, s.backupPath(1)); err != nil {
+			return fmt.Errorf("审计日志旧文件重命名失败: %v", err)
+		}
+	} else if err := os.Remove(rotating); err != nil {
+		return fmt.Errorf("审计日志旧文件删除失败: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭审计日志文件, 之后的写入将返回错误.
+func (s *FileAuditSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
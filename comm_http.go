@@ -20,16 +20,18 @@ import (
 type httpComm struct {
 	ob               *OneBot
 	config           ConfigCommHTTP
+	addr             string
 	authorizer       *httpAuthorizer
 	eventEnabled     bool
 	eventBufferSize  uint32
 	latestEvents     []marshaledEvent
 	latestEventsLock *sync.Mutex
 	latestEventsCond *sync.Cond
+	isClosing        *abool.AtomicBool
 }
 
 func (comm *httpComm) handle(w http.ResponseWriter, r *http.Request) {
-	comm.ob.Logger.Debugf("HTTP request: %v", r)
+	comm.ob.Logger.Debugf("收到来自 %v 的 HTTP (%v) 请求", r.RemoteAddr, comm.addr)
 
 	// reject unsupported methods
 	if r.Method != "POST" {
@@ -39,7 +41,8 @@ func (comm *httpComm) handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// authorization
-	if !comm.authorizer.authorize(r) {
+	principal, ok := comm.authorizer.authorize(r)
+	if !ok {
 		comm.ob.Logger.Errorf("请求鉴权失败")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
@@ -70,14 +73,18 @@ func (comm *httpComm) handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	request, err := decodeRequest(bodyBytes, isBinary, RequestComm{
-		Method: CommMethodHTTP,
-		Config: comm.config,
+	ctx := contextWithTraceParent(r.Context(), r.Header.Get("traceparent"))
+	request, err := comm.ob.decodeRequest(ctx, bodyBytes, isBinary, RequestComm{
+		Method:     CommMethodHTTP,
+		Config:     comm.config,
+		RemoteAddr: r.RemoteAddr,
+		Principal:  principal,
 	})
 	if err != nil {
 		comm.fail(w, RetCodeBadRequest, "动作请求解析失败, 错误: %v", err)
 		return
 	}
+	ctx = request.Context()
 
 	var response Response
 	if comm.eventEnabled && request.Action == ActionGetLatestEvents {
@@ -87,7 +94,7 @@ func (comm *httpComm) handle(w http.ResponseWriter, r *http.Request) {
 		response = comm.ob.handleRequest(&request)
 	}
 
-	respBytes, _ := comm.ob.encodeResponse(response, isBinary)
+	respBytes, _ := comm.ob.encodeResponse(ctx, response, isBinary)
 	w.Write(respBytes)
 }
 
@@ -116,7 +123,7 @@ func (comm *httpComm) handleGetLatestEvents(r *Request) (resp Response) {
 	comm.latestEventsLock.Lock()
 	defer comm.latestEventsLock.Unlock()
 
-	if timeout > 0 && len(comm.latestEvents) == 0 {
+	if timeout > 0 && len(comm.latestEvents) == 0 && comm.isClosing.IsNotSet() {
 		// wait for new events or timeout
 		isTimeout := abool.New()
 		timer := time.AfterFunc(time.Duration(timeout)*time.Millisecond, func() {
@@ -126,7 +133,7 @@ func (comm *httpComm) handleGetLatestEvents(r *Request) (resp Response) {
 		})
 		for {
 			comm.latestEventsCond.Wait()
-			if len(comm.latestEvents) > 0 || isTimeout.IsSet() {
+			if len(comm.latestEvents) > 0 || isTimeout.IsSet() || comm.isClosing.IsSet() {
 				break
 			}
 		}
@@ -143,25 +150,25 @@ func (comm *httpComm) handleGetLatestEvents(r *Request) (resp Response) {
 		events = append(events, event.raw)
 	}
 	comm.latestEvents = comm.latestEvents[limit:]
+	comm.ob.Metrics.latestEventsDepthChanged(comm.addr, len(comm.latestEvents))
 	w.WriteData(events)
 	return
 }
 
 func (comm *httpComm) fail(w http.ResponseWriter, retcode int, errFormat string, args ...interface{}) {
 	err := fmt.Errorf(errFormat, args...)
-	comm.ob.Logger.Warn(err)
+	comm.ob.Logger.Warnf("%v", err)
 	json.NewEncoder(w).Encode(failedResponse(retcode, err))
 }
 
-func commRunHTTP(c ConfigCommHTTP, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
-
+func commStartHTTP(c ConfigCommHTTP, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
 	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
 	ob.Logger.Infof("正在启动 HTTP (%v)...", addr)
 
 	comm := &httpComm{
 		ob:     ob,
 		config: c,
+		addr:   addr,
 		authorizer: &httpAuthorizer{
 			accessToken: c.AccessToken,
 		},
@@ -169,21 +176,33 @@ func commRunHTTP(c ConfigCommHTTP, ob *OneBot, ctx context.Context, wg *sync.Wai
 		eventBufferSize:  c.EventBufferSize,
 		latestEvents:     make([]marshaledEvent, 0),
 		latestEventsLock: &sync.Mutex{},
+		isClosing:        abool.New(),
 	}
 	comm.latestEventsCond = sync.NewCond(comm.latestEventsLock)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", comm.handle)
+	if c.Health {
+		ob.handleHealth(mux)
+	}
 	server := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
+	component := componentName(CommMethodHTTP, addr)
+	if err := ob.listenAndServe(server, component, fmt.Sprintf("HTTP (%v)", addr), wg); err != nil {
+		return err
+	}
 
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			ob.Logger.Errorf("HTTP (%v) 启动失败, 错误: %v", addr, err)
-		}
-	}()
+	wg.Add(1)
+	go comm.run(ctx, wg, server, component)
+	return nil
+}
+
+func (comm *httpComm) run(ctx context.Context, wg *sync.WaitGroup, server *http.Server, component string) {
+	defer wg.Done()
+	ob := comm.ob
+	defer ob.health.remove(component)
 
 	if comm.eventEnabled {
 		eventChan := ob.openEventListenChan()
@@ -202,9 +221,11 @@ func commRunHTTP(c ConfigCommHTTP, ob *OneBot, ctx context.Context, wg *sync.Wai
 				} else {
 					comm.latestEvents = append(comm.latestEvents, event)
 				}
+				comm.ob.Metrics.latestEventsDepthChanged(comm.addr, len(comm.latestEvents))
 				comm.latestEventsLock.Unlock()
 				comm.latestEventsCond.Signal() // notify someone to take the events
 			case <-ctx.Done():
+				discardEvents(eventChan)
 				break loop
 			}
 		}
@@ -212,8 +233,14 @@ func commRunHTTP(c ConfigCommHTTP, ob *OneBot, ctx context.Context, wg *sync.Wai
 		<-ctx.Done()
 	}
 
-	if err := server.Shutdown(context.TODO()); err != nil {
-		ob.Logger.Errorf("HTTP (%v) 关闭失败, 错误: %v", addr, err)
+	// wake up get_latest_events requests waiting for new events, so that shutdown won't wait for their timeout
+	comm.isClosing.Set()
+	comm.latestEventsLock.Lock()
+	comm.latestEventsCond.Broadcast()
+	comm.latestEventsLock.Unlock()
+
+	if err := ob.shutdownServer(ctx, server); err != nil {
+		ob.Logger.Errorf("HTTP (%v) 关闭失败, 错误: %v", comm.addr, err)
 	}
-	ob.Logger.Infof("HTTP (%v) 已关闭", addr)
+	ob.Logger.Infof("HTTP (%v) 已关闭", comm.addr)
 }
@@ -3,23 +3,37 @@
 
 package libonebot
 
-import "net/http"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+const principalAnonymous = "anonymous" // 未配置 access token 时的请求方身份
 
 type httpAuthorizer struct {
 	accessToken string
 }
 
-func (auth *httpAuthorizer) authorize(r *http.Request) bool {
+// authorize 对请求进行鉴权, 返回请求方身份和是否通过鉴权.
+//
+// 通过 access token 鉴权时, 请求方身份为 `token:` 加 access token 的 SHA-256 摘要前 8 位, 以区分不同的凭据而不泄露其内容.
+func (auth *httpAuthorizer) authorize(r *http.Request) (string, bool) {
 	if auth.accessToken != "" {
 		// try authorize with `Authorization` header
 		if r.Header.Get("Authorization") == "Bearer "+auth.accessToken {
-			return true
+			return auth.tokenPrincipal(), true
 		}
 		// try authorize with `access_token` query parameter
 		if r.URL.Query().Get("access_token") == auth.accessToken {
-			return true
+			return auth.tokenPrincipal(), true
 		}
-		return false
+		return "", false
 	}
-	return true
+	return principalAnonymous, true
+}
+
+func (auth *httpAuthorizer) tokenPrincipal() string {
+	sum := sha256.Sum256([]byte(auth.accessToken))
+	return "token:" + hex.EncodeToString(sum[:4])
 }
@@ -6,6 +6,8 @@ package libonebot
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -20,10 +22,22 @@ type httpWebhookComm struct {
 	url         string
 	accessToken string
 	httpClient  *http.Client
+	posts       *inflightTracker
+	postCtx     context.Context // 推送请求使用的 context, 强制关闭时被取消
+	cancelPosts context.CancelFunc
 }
 
 func (comm *httpWebhookComm) post(event marshaledEvent) {
-	req, _ := http.NewRequest(http.MethodPost, comm.url, bytes.NewReader(event.bytes))
+	ctx := comm.postCtx
+	if event.spanContext.IsValid() {
+		ctx = ContextWithSpanContext(ctx, event.spanContext)
+	}
+	ctx, span := comm.ob.Tracer.Start(ctx, "onebot.webhook_post")
+	defer span.End()
+	span.SetAttribute("onebot.event", event.name)
+	span.SetAttribute("http.url", comm.url)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, comm.url, bytes.NewReader(event.bytes))
 	req.Header.Set("Content-Type", "application/json")
 	if comm.accessToken != "" {
 		req.Header.Set("Authorization", "Bearer "+comm.accessToken)
@@ -31,18 +45,25 @@ func (comm *httpWebhookComm) post(event marshaledEvent) {
 	req.Header.Set("User-Agent", comm.ob.GetUserAgent())
 	req.Header.Set("X-OneBot-Version", OneBotVersion)
 	req.Header.Set("X-Impl", comm.ob.Impl)
+	injectTraceParent(ctx, req.Header)
 
 	resp, err := comm.httpClient.Do(req)
 	if err != nil {
 		comm.ob.Logger.Errorf("通过 HTTP Webhook (%v) 推送事件 `%v` 失败, 错误: %v", comm.url, event.name, err)
+		comm.ob.Metrics.webhookDelivered(comm.url, false)
+		span.SetError(err)
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.status_code", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		comm.ob.Logger.Errorf("通过 HTTP Webhook (%v) 推送事件 `%v` 失败, 状态码: %v", comm.url, event.name, resp.StatusCode)
+		comm.ob.Metrics.webhookDelivered(comm.url, false)
+		span.SetError(fmt.Errorf("状态码: %v", resp.StatusCode))
 		return
 	}
+	comm.ob.Metrics.webhookDelivered(comm.url, true)
 
 	if resp.StatusCode == http.StatusOK {
 		// handle action requests in the response body
@@ -65,33 +86,38 @@ func (comm *httpWebhookComm) post(event marshaledEvent) {
 			return
 		}
 		requests, err := decodeRequestList(respBody, isBinary, RequestComm{
-			Method: CommMethodHTTPWebhook,
-			Config: comm.config,
+			Method:     CommMethodHTTPWebhook,
+			Config:     comm.config,
+			RemoteAddr: req.URL.Host,
+			Principal:  urlLabel(comm.url),
 		})
 		if err != nil {
 			comm.ob.Logger.Warnf("动作请求列表解析失败, 已忽略, 错误: %v", err)
 			return
 		}
 		for _, request := range requests {
+			if request.ctx == nil {
+				request.ctx = ctx
+			}
 			comm.ob.handleRequest(&request) // response is ignored
 		}
 	}
 }
 
-func commRunHTTPWebhook(c ConfigCommHTTPWebhook, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
-
+func commStartHTTPWebhook(c ConfigCommHTTPWebhook, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
 	ob.Logger.Infof("正在启动 HTTP Webhook (%v)...", c.URL)
+	component := componentName(CommMethodHTTPWebhook, c.URL)
 
 	u, err := url.Parse(c.URL)
 	if err != nil {
-		ob.Logger.Errorf("HTTP Webhook (%v) 启动失败, URL 不合法, 错误: %v", c.URL, err)
-		return
+		ob.health.set(component, err)
+		return fmt.Errorf("HTTP Webhook (%v) 启动失败, URL 不合法, 错误: %v", c.URL, err)
 	}
 	if u.Scheme != "http" && u.Scheme != "https" {
-		ob.Logger.Errorf("HTTP Webhook (%v) 启动失败, URL 不合法, 必须使用 HTTP 或 HTTPS 协议", c.URL)
-		return
+		ob.health.set(component, errors.New("URL 必须使用 HTTP 或 HTTPS 协议"))
+		return fmt.Errorf("HTTP Webhook (%v) 启动失败, URL 不合法, 必须使用 HTTP 或 HTTPS 协议", c.URL)
 	}
+	ob.health.set(component, nil)
 
 	comm := &httpWebhookComm{
 		ob:          ob,
@@ -101,18 +127,41 @@ func commRunHTTPWebhook(c ConfigCommHTTPWebhook, ob *OneBot, ctx context.Context
 		httpClient: &http.Client{
 			Timeout: time.Duration(c.Timeout) * time.Millisecond, // 0 for no timeout
 		},
+		posts: newInflightTracker(),
 	}
+	comm.postCtx, comm.cancelPosts = context.WithCancel(context.Background())
 
 	eventChan := ob.openEventListenChan()
-	defer ob.closeEventListenChan(eventChan)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ob.health.remove(component)
+		defer ob.closeEventListenChan(eventChan)
+		comm.run(ctx, eventChan)
+	}()
+	return nil
+}
 
+func (comm *httpWebhookComm) run(ctx context.Context, eventChan <-chan marshaledEvent) {
 	for {
 		select {
 		case event := <-eventChan:
+			if !comm.posts.begin() {
+				continue
+			}
 			comm.ob.Logger.Debugf("通过 HTTP Webhook (%v) 推送事件 `%v`", comm.url, event.name)
-			go comm.post(event)
+			go func() {
+				defer comm.posts.done()
+				comm.post(event)
+			}()
 		case <-ctx.Done():
-			ob.Logger.Infof("HTTP Webhook (%v) 已关闭", c.URL)
+			discardEvents(eventChan)
+			// wait for pending posts and the action requests in their responses
+			if !comm.posts.drain(comm.ob.drainContext(ctx)) {
+				comm.ob.Logger.Warnf("HTTP Webhook (%v) 等待事件推送完成超时, 未完成的推送已取消", comm.url)
+			}
+			comm.cancelPosts()
+			comm.ob.Logger.Infof("HTTP Webhook (%v) 已关闭", comm.url)
 			return
 		}
 	}
@@ -0,0 +1,710 @@
+// OneBot 11 兼容通信方式
+// https://github.com/botuniverse/onebot-11
+
+package libonebot
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/botuniverse/go-libonebot/utils"
+	"github.com/tidwall/gjson"
+)
+
+// OneBot 11 返回码
+const (
+	v11RetCodeOK                = 0
+	v11RetCodeBadRequest        = 1400
+	v11RetCodeUnsupportedAction = 1404
+)
+
+const v11ProtocolVersion = "v11"
+
+// v11IDMapperCapacity 是 v11IDMapper 最多记录的 ID 映射数量.
+const v11IDMapperCapacity = 100000
+
+// v11IDMapper 在 OneBot 12 的字符串 ID 与 OneBot 11 的整数 ID 之间转换.
+//
+// 十进制非负整数形式的 ID 直接转换, 其它 ID 按出现顺序分配负整数, 负整数不会被重复分配.
+// 最多记录 v11IDMapperCapacity 个映射, 超出时淘汰最久未被转换的映射, 被淘汰的负整数 ID 无法再转换回原来的字符串 ID.
+type v11IDMapper struct {
+	lock     *sync.Mutex
+	capacity int
+	toV11    map[string]*list.Element
+	fromV11  map[int64]*list.Element
+	order    *list.List // front: most recently used, back: least recently used
+	lastV11  int64
+}
+
+type v11IDEntry struct {
+	id  string
+	v11 int64
+}
+
+func newV11IDMapper(capacity int) *v11IDMapper {
+	return &v11IDMapper{
+		lock:     &sync.Mutex{},
+		capacity: capacity,
+		toV11:    make(map[string]*list.Element),
+		fromV11:  make(map[int64]*list.Element),
+		order:    list.New(),
+		lastV11:  0,
+	}
+}
+
+func (m *v11IDMapper) toInt(id string) int64 {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n >= 0 && strconv.FormatInt(n, 10) == id {
+		return n
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if e, ok := m.toV11[id]; ok {
+		m.order.MoveToFront(e)
+		return e.Value.(*v11IDEntry).v11
+	}
+	m.lastV11--
+	e := m.order.PushFront(&v11IDEntry{id, m.lastV11})
+	m.toV11[id] = e
+	m.fromV11[m.lastV11] = e
+	if m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.toV11, oldest.Value.(*v11IDEntry).id)
+		delete(m.fromV11, oldest.Value.(*v11IDEntry).v11)
+	}
+	return m.lastV11
+}
+
+func (m *v11IDMapper) toString(id int64) string {
+	if id < 0 {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if e, ok := m.fromV11[id]; ok {
+			m.order.MoveToFront(e)
+			return e.Value.(*v11IDEntry).id
+		}
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// v11Adapter 在 OneBot 11 与 OneBot 12 的动作和事件之间转换, 同一 OneBot 实例的所有 OneBot 11 通信方式共享.
+type v11Adapter struct {
+	ob  *OneBot
+	ids *v11IDMapper
+}
+
+func newV11Adapter(ob *OneBot) *v11Adapter {
+	return &v11Adapter{
+		ob:  ob,
+		ids: newV11IDMapper(v11IDMapperCapacity),
+	}
+}
+
+// 消息转换
+
+func (a *v11Adapter) messageToV11(m Message) string {
+	converted := make(Message, 0, len(m))
+	for _, s := range m {
+		s = s.Copy()
+		switch s.Type {
+		case SegTypeMention:
+			a.mapIDField(s.Data, "user_id", true)
+		case SegTypeReply:
+			a.mapIDField(s.Data, "message_id", true)
+			a.mapIDField(s.Data, "user_id", true)
+		}
+		converted = append(converted, s)
+	}
+	return converted.ToCQCode()
+}
+
+func (a *v11Adapter) messageFromV11(params EasierMap, key string, autoEscape bool) (Message, error) {
+	val, err := params.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var m Message
+	switch v := val.(type) {
+	case string:
+		if autoEscape {
+			m = Message{TextSegment(v)}
+		} else if m, err = ParseCQCode(v); err != nil {
+			return nil, err
+		}
+	case []interface{}, map[string]interface{}:
+		list, ok := v.([]interface{})
+		if !ok {
+			list = []interface{}{v}
+		}
+		for _, item := range list {
+			segMap, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("消息段格式错误")
+			}
+			type_, _ := segMap["type"].(string)
+			data, _ := segMap["data"].(map[string]interface{})
+			strData := make(map[string]string, len(data))
+			for k, v := range data {
+				strData[k] = v11ParamString(v)
+			}
+			seg, err := segmentFromCQParams(type_, strData)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, seg)
+		}
+	default:
+		return nil, errors.New("消息格式错误")
+	}
+	for _, s := range m {
+		switch s.Type {
+		case SegTypeMention:
+			a.mapIDField(s.Data, "user_id", false)
+		case SegTypeReply:
+			a.mapIDField(s.Data, "message_id", false)
+			a.mapIDField(s.Data, "user_id", false)
+		}
+	}
+	return m, nil
+}
+
+// v11ParamString 将数组格式消息段数据中的值转换为 CQ 码参数值, JSON 数字不使用科学计数法 (如 QQ 号 1234567890).
+func v11ParamString(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// mapField 转换 map 中的 ID 字段, toV11 为 true 时从字符串转为整数, 否则从整数 (或整数字符串) 转为字符串.
+func (m *v11IDMapper) mapField(data EasierMap, key string, toV11 bool) {
+	if toV11 {
+		if s, err := data.GetString(key); err == nil && s != "" {
+			data.Set(key, m.toInt(s))
+		}
+	} else {
+		if n, err := data.GetInt64(key); err == nil {
+			data.Set(key, m.toString(n))
+		}
+	}
+}
+
+func (a *v11Adapter) mapIDField(m EasierMap, key string, toV11 bool) {
+	a.ids.mapField(m, key, toV11)
+}
+
+// 事件转换
+
+// translateEvent 将 OneBot 12 事件转换为 OneBot 11 事件, 无法转换时返回 nil.
+func (a *v11Adapter) translateEvent(event marshaledEvent) []byte {
+	m, ok := gjson.ParseBytes(event.bytes).Value().(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	e := EasierMapFromMap(m)
+	type_, _ := e.GetString("type")
+	detailType, _ := e.GetString("detail_type")
+	subType, _ := e.GetString("sub_type")
+	t, _ := e.GetFloat64("time")
+
+	v11 := map[string]interface{}{
+		"time": int64(t),
+	}
+	if self, err := e.GetMap("self"); err == nil {
+		selfID, _ := self.GetString("user_id")
+		v11["self_id"] = a.ids.toInt(selfID)
+	} else if a.ob.Self != nil {
+		v11["self_id"] = a.ids.toInt(a.ob.Self.UserID)
+	}
+	id := func(key string) int64 {
+		s, _ := e.GetString(key)
+		return a.ids.toInt(s)
+	}
+
+	switch type_ + "." + detailType {
+	case "message.private", "message.group":
+		message, err := e.GetMessage("message")
+		if err != nil {
+			return nil
+		}
+		cq := a.messageToV11(message)
+		v11["post_type"] = "message"
+		v11["message_type"] = detailType
+		v11["message_id"] = id("message_id")
+		v11["user_id"] = id("user_id")
+		v11["message"] = cq
+		v11["raw_message"] = cq
+		v11["font"] = 0
+		v11["sender"] = map[string]interface{}{"user_id": id("user_id")}
+		if detailType == "group" {
+			v11["sub_type"] = "normal"
+			v11["group_id"] = id("group_id")
+			v11["anonymous"] = nil
+		} else {
+			v11["sub_type"] = "friend"
+		}
+	case "notice.friend_increase":
+		v11["post_type"] = "notice"
+		v11["notice_type"] = "friend_add"
+		v11["user_id"] = id("user_id")
+	case "notice.private_message_delete":
+		v11["post_type"] = "notice"
+		v11["notice_type"] = "friend_recall"
+		v11["user_id"] = id("user_id")
+		v11["message_id"] = id("message_id")
+	case "notice.group_member_increase", "notice.group_member_decrease":
+		v11["post_type"] = "notice"
+		if detailType == "group_member_increase" {
+			v11["notice_type"] = "group_increase"
+			if subType != GroupMemberIncreaseNoticeEventSubTypeInvite {
+				subType = "approve"
+			}
+		} else {
+			v11["notice_type"] = "group_decrease"
+			if subType != GroupMemberDecreaseNoticeEventSubTypeKick {
+				subType = GroupMemberDecreaseNoticeEventSubTypeLeave
+			}
+		}
+		v11["sub_type"] = subType
+		v11["group_id"] = id("group_id")
+		v11["user_id"] = id("user_id")
+		v11["operator_id"] = id("operator_id")
+	case "notice.group_message_delete":
+		v11["post_type"] = "notice"
+		v11["notice_type"] = "group_recall"
+		v11["group_id"] = id("group_id")
+		v11["user_id"] = id("user_id")
+		v11["operator_id"] = id("operator_id")
+		v11["message_id"] = id("message_id")
+	case "meta.heartbeat":
+		interval, _ := e.GetInt64("interval")
+		v11["post_type"] = "meta_event"
+		v11["meta_event_type"] = "heartbeat"
+		v11["interval"] = interval
+		v11["status"] = map[string]interface{}{"online": true, "good": true}
+	default:
+		return nil
+	}
+
+	b, err := json.Marshal(v11)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// 动作转换
+
+type v11Action struct {
+	action string                                                           // 对应的 OneBot 12 动作, 为空表示直接由适配器响应
+	params func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) // 转换请求参数, 为 nil 表示无参数
+	result func(a *v11Adapter, p EasierMap, d EasierMap) interface{}        // 由请求参数和 OneBot 12 响应数据生成响应数据, 为 nil 表示无数据
+}
+
+func v11UserInfo(a *v11Adapter, d EasierMap) map[string]interface{} {
+	userID, _ := d.GetString("user_id")
+	nickname, _ := d.GetString("user_name")
+	return map[string]interface{}{
+		"user_id":  a.ids.toInt(userID),
+		"nickname": nickname,
+	}
+}
+
+func v11GroupInfo(a *v11Adapter, d EasierMap) map[string]interface{} {
+	groupID, _ := d.GetString("group_id")
+	groupName, _ := d.GetString("group_name")
+	return map[string]interface{}{
+		"group_id":         a.ids.toInt(groupID),
+		"group_name":       groupName,
+		"member_count":     0,
+		"max_member_count": 0,
+	}
+}
+
+func v11GroupMemberInfo(a *v11Adapter, p EasierMap, d EasierMap) map[string]interface{} {
+	info := v11UserInfo(a, d)
+	groupID, _ := p.GetInt64("group_id")
+	card, _ := d.GetString("user_displayname")
+	info["group_id"] = groupID
+	info["card"] = card
+	info["role"] = "member"
+	return info
+}
+
+func v11List(d EasierMap, convert func(EasierMap) map[string]interface{}) interface{} {
+	list := make([]interface{}, 0)
+	items, _ := d.GetMapArray("list")
+	for _, item := range items {
+		list = append(list, convert(item))
+	}
+	return list
+}
+
+// v11IDParams 生成一个参数转换函数, 将指定的 OneBot 11 整数 ID 参数转换为 OneBot 12 字符串 ID.
+func v11IDParams(keys ...string) func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) {
+	return func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) {
+		params := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			n, err := p.GetInt64(key)
+			if err != nil {
+				return nil, err
+			}
+			params[key] = a.ids.toString(n)
+		}
+		return params, nil
+	}
+}
+
+func v11SendMessageParams(detailType string) func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) {
+	return func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) {
+		detailType := detailType
+		if detailType == "" {
+			// send_msg: infer the message type from the ID params if not specified
+			detailType, _ = p.GetString("message_type")
+			if detailType == "" {
+				if _, err := p.Get("group_id"); err == nil {
+					detailType = "group"
+				} else {
+					detailType = "private"
+				}
+			}
+		}
+		var idKey string
+		switch detailType {
+		case "private":
+			idKey = "user_id"
+		case "group":
+			idKey = "group_id"
+		default:
+			return nil, errors.New("`message_type` 字段是无效值")
+		}
+		params, err := v11IDParams(idKey)(a, p)
+		if err != nil {
+			return nil, err
+		}
+		autoEscape, _ := p.GetBool("auto_escape")
+		message, err := a.messageFromV11(p, "message", autoEscape)
+		if err != nil {
+			return nil, err
+		}
+		params["detail_type"] = detailType
+		params["message"] = message
+		return params, nil
+	}
+}
+
+func v11MessageIDResult(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+	messageID, _ := d.GetString("message_id")
+	return map[string]interface{}{"message_id": a.ids.toInt(messageID)}
+}
+
+func v11CanSend(segType string) v11Action {
+	return v11Action{
+		result: func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+			_, ok := a.ob.Segments.Lookup(segType)
+			return map[string]interface{}{"yes": ok}
+		},
+	}
+}
+
+var v11Actions = map[string]v11Action{
+	"send_private_msg": {ActionSendMessage, v11SendMessageParams("private"), v11MessageIDResult},
+	"send_group_msg":   {ActionSendMessage, v11SendMessageParams("group"), v11MessageIDResult},
+	"send_msg":         {ActionSendMessage, v11SendMessageParams(""), v11MessageIDResult},
+	"delete_msg":       {ActionDeleteMessage, v11IDParams("message_id"), nil},
+	"get_login_info": {ActionGetSelfInfo, nil, func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11UserInfo(a, d)
+	}},
+	"get_stranger_info": {ActionGetUserInfo, v11IDParams("user_id"), func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		info := v11UserInfo(a, d)
+		info["sex"] = "unknown"
+		info["age"] = 0
+		return info
+	}},
+	"get_friend_list": {ActionGetFriendList, nil, func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11List(d, func(m EasierMap) map[string]interface{} {
+			info := v11UserInfo(a, m)
+			info["remark"], _ = m.GetString("user_remark")
+			return info
+		})
+	}},
+	"get_group_info": {ActionGetGroupInfo, v11IDParams("group_id"), func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11GroupInfo(a, d)
+	}},
+	"get_group_list": {ActionGetGroupList, nil, func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11List(d, func(m EasierMap) map[string]interface{} {
+			return v11GroupInfo(a, m)
+		})
+	}},
+	"get_group_member_info": {ActionGetGroupMemberInfo, v11IDParams("group_id", "user_id"), func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11GroupMemberInfo(a, p, d)
+	}},
+	"get_group_member_list": {ActionGetGroupMemberList, v11IDParams("group_id"), func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		return v11List(d, func(m EasierMap) map[string]interface{} {
+			return v11GroupMemberInfo(a, p, m)
+		})
+	}},
+	"set_group_name": {ActionSetGroupName, func(a *v11Adapter, p EasierMap) (map[string]interface{}, error) {
+		params, err := v11IDParams("group_id")(a, p)
+		if err != nil {
+			return nil, err
+		}
+		if params["group_name"], err = p.GetString("group_name"); err != nil {
+			return nil, err
+		}
+		return params, nil
+	}, nil},
+	"set_group_leave": {ActionLeaveGroup, v11IDParams("group_id"), nil},
+	"get_status": {ActionGetStatus, nil, func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		good, _ := d.GetBool("good")
+		online := false
+		bots, _ := d.GetMapArray("bots")
+		for _, bot := range bots {
+			if o, _ := bot.GetBool("online"); o {
+				online = true
+			}
+		}
+		return map[string]interface{}{"online": online, "good": good}
+	}},
+	"get_version_info": {ActionGetVersion, nil, func(a *v11Adapter, p EasierMap, d EasierMap) interface{} {
+		impl, _ := d.GetString("impl")
+		version, _ := d.GetString("version")
+		return map[string]interface{}{
+			"app_name":         impl,
+			"app_version":      version,
+			"protocol_version": v11ProtocolVersion,
+		}
+	}},
+	"can_send_image":  v11CanSend(SegTypeImage),
+	"can_send_record": v11CanSend(SegTypeVoice),
+}
+
+// handleAction 处理一个 OneBot 11 动作请求, 返回 OneBot 11 动作响应.
+func (a *v11Adapter) handleAction(action string, params EasierMap, echo interface{}, reqComm RequestComm) map[string]interface{} {
+	resp := map[string]interface{}{
+		"status":  statusFailed,
+		"retcode": v11RetCodeOK,
+		"data":    nil,
+		"msg":     "",
+	}
+	if echo != nil {
+		resp["echo"] = echo
+	}
+	fail := func(retCode int, err error) map[string]interface{} {
+		a.ob.Logger.Warnf("OneBot 11 动作请求 `%v` 处理失败, 错误: %v", action, err)
+		resp["retcode"] = retCode
+		resp["msg"] = err.Error()
+		return resp
+	}
+
+	v11Action, ok := v11Actions[action]
+	if !ok {
+		return fail(v11RetCodeUnsupportedAction, fmt.Errorf("动作 `%v` 不存在", action))
+	}
+
+	data := EasierMapFromMap(make(map[string]interface{}))
+	if v11Action.action != "" {
+		v12Params := make(map[string]interface{})
+		if v11Action.params != nil {
+			var err error
+			if v12Params, err = v11Action.params(a, params); err != nil {
+				return fail(v11RetCodeBadRequest, errorParam(err))
+			}
+		}
+		v12Resp := a.ob.handleRequest(&Request{
+			Comm:   reqComm,
+			Action: v11Action.action,
+			Params: EasierMapFromMap(v12Params),
+		})
+		if v12Resp.Status != statusOK {
+			retCode := v12Resp.RetCode
+			switch retCode {
+			case RetCodeUnsupportedAction:
+				retCode = v11RetCodeUnsupportedAction
+			case RetCodeBadRequest, RetCodeBadParam:
+				retCode = v11RetCodeBadRequest
+			}
+			return fail(retCode, errors.New(v12Resp.Message))
+		}
+		data = normalizeResponseData(v12Resp.Data)
+	}
+
+	resp["status"] = statusOK
+	if v11Action.result != nil {
+		resp["data"] = v11Action.result(a, params, data)
+	}
+	return resp
+}
+
+// normalizeResponseData 将任意类型的响应数据转换为 EasierMap, 列表数据放在 `list` 字段中.
+func normalizeResponseData(data interface{}) EasierMap {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return EasierMapFromMap(make(map[string]interface{}))
+	}
+	switch v := gjson.ParseBytes(b).Value().(type) {
+	case map[string]interface{}:
+		return EasierMapFromMap(v)
+	case []interface{}:
+		return EasierMapFromMap(map[string]interface{}{"list": v})
+	default:
+		return EasierMapFromMap(make(map[string]interface{}))
+	}
+}
+
+// handleRequestBytes 处理通过 WebSocket 收到的 OneBot 11 动作请求, 返回编码后的动作响应.
+func (a *v11Adapter) handleRequestBytes(requestBytes []byte, reqComm RequestComm) []byte {
+	var resp map[string]interface{}
+	m, ok := gjson.Parse(utils.BytesToString(requestBytes)).Value().(map[string]interface{})
+	if !ok || !gjson.ValidBytes(requestBytes) {
+		resp = map[string]interface{}{
+			"status":  statusFailed,
+			"retcode": v11RetCodeBadRequest,
+			"data":    nil,
+			"msg":     "动作请求解析失败, 不是一个 JSON 对象",
+		}
+	} else {
+		r := EasierMapFromMap(m)
+		action, _ := r.GetString("action")
+		params, err := r.GetMap("params")
+		if err != nil {
+			params = EasierMapFromMap(make(map[string]interface{}))
+		}
+		echo, _ := r.Get("echo")
+		resp = a.handleAction(action, params, echo, reqComm)
+	}
+	respBytes, _ := json.Marshal(resp)
+	return respBytes
+}
+
+// OneBot 11 HTTP 通信方式
+
+type v11HTTPComm struct {
+	ob         *OneBot
+	config     ConfigCommV11HTTP
+	authorizer *httpAuthorizer
+}
+
+func (comm *v11HTTPComm) handle(w http.ResponseWriter, r *http.Request) {
+	comm.ob.Logger.Debugf("收到来自 %v 的 OneBot 11 HTTP 请求", r.RemoteAddr)
+
+	if r.Method != "GET" && r.Method != "POST" {
+		comm.ob.Logger.Errorf("动作请求不支持通过 %v 方式请求", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// authorization
+	principal, ok := comm.authorizer.authorize(r)
+	if !ok {
+		comm.ob.Logger.Errorf("请求鉴权失败")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	action := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if _, ok := v11Actions[action]; !ok {
+		comm.ob.Logger.Errorf("OneBot 11 动作 `%v` 不存在", action)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	params := make(map[string]interface{})
+	for k, v := range r.URL.Query() {
+		if k != "access_token" && len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+	if r.Method == "POST" {
+		contentType := r.Header.Get("Content-Type")
+		switch {
+		case strings.HasPrefix(contentType, "application/json"):
+			bodyBytes, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, ok := gjson.ParseBytes(bodyBytes).Value().(map[string]interface{})
+			if !ok || !gjson.ValidBytes(bodyBytes) {
+				comm.ob.Logger.Errorf("OneBot 11 动作请求解析失败, 不是一个 JSON 对象")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for k, v := range body {
+				params[k] = v
+			}
+		case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for k, v := range r.PostForm {
+				if len(v) > 0 {
+					params[k] = v[0]
+				}
+			}
+		default:
+			comm.ob.Logger.Errorf("请求头中的 Content-Type 不支持")
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	resp := comm.ob.v11.handleAction(action, EasierMapFromMap(params), nil, RequestComm{
+		Method:     CommMethodV11HTTP,
+		Config:     comm.config,
+		RemoteAddr: r.RemoteAddr,
+		Principal:  principal,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func commStartV11HTTP(c ConfigCommV11HTTP, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	ob.Logger.Infof("正在启动 OneBot 11 HTTP (%v)...", addr)
+
+	comm := &v11HTTPComm{
+		ob:     ob,
+		config: c,
+		authorizer: &httpAuthorizer{
+			accessToken: c.AccessToken,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", comm.handle)
+	if c.Health {
+		ob.handleHealth(mux)
+	}
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	component := componentName(CommMethodV11HTTP, addr)
+	if err := ob.listenAndServe(server, component, fmt.Sprintf("OneBot 11 HTTP (%v)", addr), wg); err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ob.health.remove(component)
+
+		<-ctx.Done()
+		if err := ob.shutdownServer(ctx, server); err != nil {
+			ob.Logger.Errorf("OneBot 11 HTTP (%v) 关闭失败, 错误: %v", addr, err)
+		}
+		ob.Logger.Infof("OneBot 11 HTTP (%v) 已关闭", addr)
+	}()
+	return nil
+}
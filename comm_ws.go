@@ -8,35 +8,79 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/tevino/abool/v2"
 )
 
 type wsCommCommon struct {
-	ob *OneBot
+	ob   *OneBot
+	name string      // 通信方式名称, 用于日志
+	v11  *v11Adapter // 不为 nil 时使用 OneBot 11 的动作和事件格式
 }
 
 func (comm *wsCommCommon) handleRequest(conn *websocket.Conn, connWriteLock *sync.Mutex, messageBytes []byte, messageType int, reqComm RequestComm) {
-	isBinary := messageType == websocket.BinaryMessage
-	resp := comm.ob.decodeAndHandleRequest(messageBytes, isBinary, reqComm)
-	respBytes, _ := comm.ob.encodeResponse(resp, isBinary)
+	var respBytes []byte
+	if comm.v11 != nil {
+		respBytes = comm.v11.handleRequestBytes(messageBytes, reqComm)
+		messageType = websocket.TextMessage
+	} else {
+		isBinary := messageType == websocket.BinaryMessage
+		ctx, resp := comm.ob.decodeAndHandleRequest(context.Background(), messageBytes, isBinary, reqComm)
+		respBytes, _ = comm.ob.encodeResponse(ctx, resp, isBinary)
+	}
 	connWriteLock.Lock()
 	conn.WriteMessage(messageType, respBytes)
 	connWriteLock.Unlock()
 }
 
+// closeGracefully 停止处理连接上的新动作请求, 等待正在处理的动作请求完成后发送关闭帧, 对方未及时关闭连接时强制关闭.
+//
+// 参数:
+//   ctx: 通信方式的运行 context, 用于获取等待动作请求完成的截止时间
+//   conn: 要关闭的连接
+//   requests: 连接上的动作请求
+//   readerDone: 读取连接的 goroutine 退出后关闭
+//   addr: 日志中的连接地址
+func (comm *wsCommCommon) closeGracefully(ctx context.Context, conn *websocket.Conn, requests *inflightTracker, readerDone <-chan struct{}, addr string) {
+	drainCtx := comm.ob.drainContext(ctx)
+	if !requests.drain(drainCtx) {
+		comm.ob.Logger.Warnf("%v (%v) 等待动作请求处理完成超时", comm.name, addr)
+	}
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, wsCloseReason)
+	if err := conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(wsCloseTimeout)); err == nil {
+		// wait for the peer to close the connection
+		select {
+		case <-readerDone:
+		case <-drainCtx.Done():
+		case <-time.After(wsCloseTimeout):
+		}
+	}
+	conn.Close() // be rude if necessary
+}
+
 func (comm *wsCommCommon) pushEvent(conn *websocket.Conn, connWriteLock *sync.Mutex, event marshaledEvent) {
+	eventBytes := event.bytes
+	if comm.v11 != nil {
+		if eventBytes = comm.v11.translateEvent(event); eventBytes == nil {
+			return // no equivalent OneBot 11 event
+		}
+	}
 	connWriteLock.Lock()
-	conn.WriteMessage(websocket.TextMessage, event.bytes)
+	conn.WriteMessage(websocket.TextMessage, eventBytes)
 	connWriteLock.Unlock()
 }
 
 type wsComm struct {
 	wsCommCommon
 	config     ConfigCommWS
+	method     int
 	addr       string
 	authorizer *httpAuthorizer
+	ctx        context.Context // 被取消时关闭所有连接
+	conns      *sync.WaitGroup // 处理中的连接
 }
 
 var wsUpgrader = websocket.Upgrader{
@@ -46,22 +90,27 @@ var wsUpgrader = websocket.Upgrader{
 }
 
 func (comm *wsComm) handle(w http.ResponseWriter, r *http.Request) {
-	comm.ob.Logger.Debugf("收到来自 %v 的 WebSocket (%v) 连接请求", r.RemoteAddr, comm.addr)
+	comm.ob.Logger.Debugf("收到来自 %v 的 %v (%v) 连接请求", r.RemoteAddr, comm.name, comm.addr)
 
 	// authorization
-	if !comm.authorizer.authorize(r) {
+	principal, ok := comm.authorizer.authorize(r)
+	if !ok {
 		comm.ob.Logger.Errorf("请求鉴权失败")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
+	comm.conns.Add(1)
+	defer comm.conns.Done()
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
-		comm.ob.Logger.Errorf("WebSocket (%v) 连接失败, 错误: %v", comm.addr, err)
+		comm.ob.Logger.Errorf("%v (%v) 连接失败, 错误: %v", comm.name, comm.addr, err)
 		return
 	}
-	comm.ob.Logger.Infof("WebSocket (%v) 连接成功", comm.addr)
+	comm.ob.Logger.Infof("%v (%v) 连接成功", comm.name, comm.addr)
 	defer conn.Close()
+	comm.ob.Metrics.wsConnectionChanged(comm.method, comm.addr, 1)
+	defer comm.ob.Metrics.wsConnectionChanged(comm.method, comm.addr, -1)
 
 	// protect concurrent writes to the same connection
 	connWriteLock := &sync.Mutex{}
@@ -70,10 +119,10 @@ func (comm *wsComm) handle(w http.ResponseWriter, r *http.Request) {
 	checkError := func(err error) bool {
 		if err != nil {
 			if isClosed.IsNotSet() {
-				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-					comm.ob.Logger.Infof("WebSocket (%v) 连接断开", comm.addr)
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) || comm.ctx.Err() != nil {
+					comm.ob.Logger.Infof("%v (%v) 连接断开", comm.name, comm.addr)
 				} else {
-					comm.ob.Logger.Errorf("WebSocket (%v) 连接异常断开, 错误: %v", comm.addr, err)
+					comm.ob.Logger.Errorf("%v (%v) 连接异常断开, 错误: %v", comm.name, comm.addr, err)
 				}
 			}
 			isClosed.Set()
@@ -88,56 +137,97 @@ func (comm *wsComm) handle(w http.ResponseWriter, r *http.Request) {
 	go func() {
 		// keep pushing events throught the connection
 		for event := range eventChan {
-			comm.ob.Logger.Debugf("通过 WebSocket (%v) 推送事件 `%v`", comm.addr, event.name)
+			comm.ob.Logger.Debugf("通过 %v (%v) 推送事件 `%v`", comm.name, comm.addr, event.name)
 			go comm.pushEvent(conn, connWriteLock, event)
 		}
 	}()
 
+	requests := newInflightTracker()
+	readerDone := make(chan struct{})
+	closerDone := make(chan struct{})
+	go func() {
+		defer close(closerDone)
+		select {
+		case <-comm.ctx.Done():
+			comm.closeGracefully(comm.ctx, conn, requests, readerDone, r.RemoteAddr)
+		case <-readerDone:
+		}
+	}()
+
 	for {
 		// this is the only one place we read from the connection, no need to lock
 		messageType, messageBytes, err := conn.ReadMessage()
 		if checkError(err) {
 			break
 		}
-		go comm.handleRequest(conn, connWriteLock, messageBytes, messageType, RequestComm{
-			Method: CommMethodWS,
-			Config: comm.config,
-		})
+		if !requests.begin() {
+			continue // closing, ignore new requests
+		}
+		go func() {
+			defer requests.done()
+			comm.handleRequest(conn, connWriteLock, messageBytes, messageType, RequestComm{
+				Method:     comm.method,
+				Config:     comm.config,
+				RemoteAddr: r.RemoteAddr,
+				Principal:  principal,
+			})
+		}()
 	}
+	close(readerDone)
+	<-closerDone
+}
+
+func commStartWS(c ConfigCommWS, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	return startWS(c, ob, ctx, wg, "WebSocket", CommMethodWS, nil)
 }
 
-func commRunWS(c ConfigCommWS, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
+func commStartV11WS(c ConfigCommWS, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	return startWS(c, ob, ctx, wg, "OneBot 11 WebSocket", CommMethodV11WS, ob.v11)
+}
 
+func startWS(c ConfigCommWS, ob *OneBot, ctx context.Context, wg *sync.WaitGroup, name string, method int, v11 *v11Adapter) error {
 	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
-	ob.Logger.Infof("正在启动 WebSocket (%v)...", addr)
+	ob.Logger.Infof("正在启动 %v (%v)...", name, addr)
 
 	comm := &wsComm{
-		wsCommCommon: wsCommCommon{ob: ob},
+		wsCommCommon: wsCommCommon{ob: ob, name: name, v11: v11},
 		config:       c,
+		method:       method,
 		addr:         addr,
 		authorizer: &httpAuthorizer{
 			accessToken: c.AccessToken,
 		},
+		ctx:   ctx,
+		conns: &sync.WaitGroup{},
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", comm.handle)
+	if c.Health {
+		ob.handleHealth(mux)
+	}
 	server := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
+	component := componentName(method, addr)
+	if err := ob.listenAndServe(server, component, fmt.Sprintf("%v (%v)", name, addr), wg); err != nil {
+		return err
+	}
 
+	wg.Add(1)
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			ob.Logger.Errorf("WebSocket (%v) 启动失败, 错误: %v", addr, err)
+		defer wg.Done()
+		defer ob.health.remove(component)
+
+		<-ctx.Done()
+		err := ob.shutdownServer(ctx, server)
+		comm.conns.Wait() // hijacked connections are not tracked by the server
+		if err != nil {
+			ob.Logger.Errorf("%v (%v) 关闭失败, 错误: %v", name, addr, err)
+		} else {
+			ob.Logger.Infof("%v (%v) 已关闭", name, addr)
 		}
 	}()
-
-	<-ctx.Done()
-	if err := server.Shutdown(context.TODO()); err != nil {
-		ob.Logger.Errorf("WebSocket (%v) 关闭失败, 错误: %v", addr, err)
-	} else {
-		ob.Logger.Infof("WebSocket (%v) 已关闭", addr)
-	}
+	return nil
 }
@@ -5,8 +5,11 @@ package libonebot
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -17,6 +20,8 @@ import (
 type wsReverseComm struct {
 	wsCommCommon
 	config            ConfigCommWSReverse
+	method            int
+	component         string // 就绪状态中的组件名称
 	url               string
 	accessToken       string
 	reconnectInterval time.Duration
@@ -24,20 +29,34 @@ type wsReverseComm struct {
 }
 
 func (comm *wsReverseComm) connectAndServe(ctx context.Context) {
-	comm.ob.Logger.Debugf("WebSocket Reverse (%v) 开始连接", comm.url)
+	comm.ob.Logger.Debugf("%v (%v) 开始连接", comm.name, comm.url)
 
 	header := http.Header{}
 	if comm.accessToken != "" {
 		header.Set("Authorization", "Bearer "+comm.accessToken)
 	}
 	header.Set("User-Agent", comm.ob.GetUserAgent())
-	header.Set("Sec-WebSocket-Protocol", OneBotVersion+"."+comm.ob.Impl)
+	if comm.v11 != nil {
+		// act as an OneBot 11 Universal client
+		header.Set("X-Client-Role", "Universal")
+		if comm.ob.Self != nil {
+			header.Set("X-Self-ID", strconv.FormatInt(comm.v11.ids.toInt(comm.ob.Self.UserID), 10))
+		}
+	} else {
+		header.Set("Sec-WebSocket-Protocol", OneBotVersion+"."+comm.ob.Impl)
+	}
 	conn, _, err := websocket.DefaultDialer.Dial(comm.url, header)
 	if err != nil {
-		comm.ob.Logger.Errorf("WebSocket Reverse (%v) 连接失败, 错误: %v", comm.url, err)
+		comm.ob.Logger.Errorf("%v (%v) 连接失败, 错误: %v", comm.name, comm.url, err)
+		comm.ob.health.set(comm.component, err)
 		return
 	}
-	comm.ob.Logger.Infof("WebSocket Reverse (%v) 连接成功", comm.url)
+	comm.ob.Logger.Infof("%v (%v) 连接成功", comm.name, comm.url)
+	defer conn.Close()
+	comm.ob.health.set(comm.component, nil)
+	defer comm.ob.health.set(comm.component, errNotConnected)
+	comm.ob.Metrics.wsConnectionChanged(comm.method, comm.url, 1)
+	defer comm.ob.Metrics.wsConnectionChanged(comm.method, comm.url, -1)
 
 	// protect concurrent writes to the same connection
 	connWriteLock := &sync.Mutex{}
@@ -48,10 +67,10 @@ func (comm *wsReverseComm) connectAndServe(ctx context.Context) {
 		if err != nil {
 			if isClosed.IsNotSet() {
 				connCancel() // this will be called for only one time
-				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-					comm.ob.Logger.Infof("WebSocket Reverse (%v) 连接断开", comm.url)
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) || ctx.Err() != nil {
+					comm.ob.Logger.Infof("%v (%v) 连接断开", comm.name, comm.url)
 				} else {
-					comm.ob.Logger.Errorf("WebSocket Reverse (%v) 连接异常断开, 错误: %v", comm.url, err)
+					comm.ob.Logger.Errorf("%v (%v) 连接异常断开, 错误: %v", comm.name, comm.url, err)
 				}
 			}
 			isClosed.Set()
@@ -60,20 +79,28 @@ func (comm *wsReverseComm) connectAndServe(ctx context.Context) {
 		return false
 	}
 
-	wsClientWG := &sync.WaitGroup{}
-	wsClientWG.Add(1)
+	requests := newInflightTracker()
+	readerDone := make(chan struct{})
 	go func() {
-		defer wsClientWG.Done()
+		defer close(readerDone)
 		for {
 			// this is the only one place we read from the connection, no need to lock
 			messageType, messageBytes, err := conn.ReadMessage()
 			if checkError(err) {
 				break
 			}
-			go comm.handleRequest(conn, connWriteLock, messageBytes, messageType, RequestComm{
-				Method: CommMethodWSReverse,
-				Config: comm.config,
-			})
+			if !requests.begin() {
+				continue // closing, ignore new requests
+			}
+			go func() {
+				defer requests.done()
+				comm.handleRequest(conn, connWriteLock, messageBytes, messageType, RequestComm{
+					Method:     comm.method,
+					Config:     comm.config,
+					RemoteAddr: conn.RemoteAddr().String(),
+					Principal:  urlLabel(comm.url),
+				})
+			}()
 		}
 	}()
 
@@ -84,48 +111,54 @@ loop:
 	for {
 		select {
 		case event := <-eventChan:
-			comm.ob.Logger.Debugf("通过 WebSocket Reverse (%v) 推送事件 `%v`", comm.url, event.name)
+			comm.ob.Logger.Debugf("通过 %v (%v) 推送事件 `%v`", comm.name, comm.url, event.name)
 			go comm.pushEvent(conn, connWriteLock, event)
 		case <-connCtx.Done(): // connection closed
 			break loop
 		case <-ctx.Done(): // onebot shutdown
-			// try close the connection gracefully
-			err = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Time{})
-			if err != nil {
-				// be rude if necessary
-				conn.Close()
-			}
 			comm.isShutdown.Set()
+			discardEvents(eventChan)
+			comm.closeGracefully(ctx, conn, requests, readerDone, comm.url)
 			break loop
 		}
 	}
 
-	wsClientWG.Wait() // wait the ws client goroutine to finish
+	<-readerDone // wait the ws client goroutine to finish
+}
+
+func commStartWSReverse(c ConfigCommWSReverse, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	return startWSReverse(c, ob, ctx, wg, "WebSocket Reverse", CommMethodWSReverse, nil)
 }
 
-func commRunWSReverse(c ConfigCommWSReverse, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) {
-	defer wg.Done()
+func commStartV11WSReverse(c ConfigCommWSReverse, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	return startWSReverse(c, ob, ctx, wg, "OneBot 11 WebSocket Reverse", CommMethodV11WSReverse, ob.v11)
+}
 
-	ob.Logger.Infof("正在启动 WebSocket Reverse (%v)...", c.URL)
+func startWSReverse(c ConfigCommWSReverse, ob *OneBot, ctx context.Context, wg *sync.WaitGroup, name string, method int, v11 *v11Adapter) error {
+	ob.Logger.Infof("正在启动 %v (%v)...", name, c.URL)
+	component := componentName(method, c.URL)
 
 	u, err := url.Parse(c.URL)
 	if err != nil {
-		ob.Logger.Errorf("WebSocket Reverse (%v) 启动失败, URL 不合法, 错误: %v", c.URL, err)
-		return
+		ob.health.set(component, err)
+		return fmt.Errorf("%v (%v) 启动失败, URL 不合法, 错误: %v", name, c.URL, err)
 	}
 	if u.Scheme != "ws" && u.Scheme != "wss" {
-		ob.Logger.Errorf("WebSocket Reverse (%v) 启动失败, URL 不合法, 必须使用 WS 或 WSS 协议", c.URL)
-		return
+		ob.health.set(component, errors.New("URL 必须使用 WS 或 WSS 协议"))
+		return fmt.Errorf("%v (%v) 启动失败, URL 不合法, 必须使用 WS 或 WSS 协议", name, c.URL)
 	}
 
 	if c.ReconnectInterval == 0 {
-		ob.Logger.Errorf("WebSocket Reverse 重连间隔必须大于 0")
-		return
+		ob.health.set(component, errors.New("重连间隔必须大于 0"))
+		return fmt.Errorf("%v (%v) 启动失败, 重连间隔必须大于 0", name, c.URL)
 	}
+	ob.health.set(component, errNotConnected)
 
 	comm := wsReverseComm{
-		wsCommCommon:      wsCommCommon{ob: ob},
+		wsCommCommon:      wsCommCommon{ob: ob, name: name, v11: v11},
 		config:            c,
+		method:            method,
+		component:         component,
 		url:               c.URL,
 		accessToken:       c.AccessToken,
 		reconnectInterval: time.Duration(c.ReconnectInterval) * time.Millisecond,
@@ -137,13 +170,30 @@ func commRunWSReverse(c ConfigCommWSReverse, ob *OneBot, ctx context.Context, wg
 		comm.isShutdown.Set()
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ob.health.remove(component)
+		comm.run(ctx)
+	}()
+	return nil
+}
+
+func (comm *wsReverseComm) run(ctx context.Context) {
 	for {
 		comm.connectAndServe(ctx)
 		if comm.isShutdown.IsSet() {
 			break
 		}
-		ob.Logger.Infof("WebSocket Reverse (%v) 将在 %v 秒后尝试重连", comm.url, c.ReconnectInterval)
-		time.Sleep(comm.reconnectInterval)
+		comm.ob.Logger.Infof("%v (%v) 将在 %v 毫秒后尝试重连", comm.name, comm.url, comm.config.ReconnectInterval)
+		select {
+		case <-time.After(comm.reconnectInterval):
+		case <-ctx.Done():
+		}
+		if comm.isShutdown.IsSet() {
+			break
+		}
+		comm.ob.Metrics.wsReconnected(comm.method, comm.url)
 	}
-	ob.Logger.Infof("WebSocket Reverse (%v) 已关闭", comm.url)
+	comm.ob.Logger.Infof("%v (%v) 已关闭", comm.name, comm.url)
 }
@@ -0,0 +1,171 @@
+// 命令解析
+
+package libonebot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CommandArg 表示命令的一个参数, 为一段纯文本或一个非文本消息段.
+type CommandArg struct {
+	Text    string   // 纯文本参数 (已去除引号和转义), 非文本参数时为空字符串
+	Segment *Segment // 非文本参数对应的消息段, 纯文本参数时为 nil
+}
+
+// IsText 判断参数是否为纯文本参数.
+func (a CommandArg) IsText() bool {
+	return a.Segment == nil
+}
+
+// Command 表示从消息中解析出的命令.
+type Command struct {
+	Name      string       // 命令名称
+	Args      []CommandArg // 命令参数
+	Prefix    string       // 匹配的命令前缀, 仅通过提及机器人触发时为空字符串
+	Mentioned bool         // 消息开头是否提及了机器人
+	Message   Message      // 原始消息
+	Event     AnyEvent     // 消息所在的事件, 通过 CommandRouter.DispatchEvent 分发时有效, 否则为 nil
+}
+
+// CommandParser 从消息中解析命令.
+//
+// 消息开头的回复消息段和空白字符将被忽略. 以 Prefixes 中的任一前缀开头的消息将被解析为命令;
+// MentionAsPrefix 为 true 时, 以提及机器人开头的消息也被解析为命令 (提及之后仍可以带有前缀).
+// Prefixes 为空且 MentionAsPrefix 为 false 时, 所有以纯文本开头的消息都被解析为命令.
+type CommandParser struct {
+	Prefixes        []string // 命令前缀, 如 `/`, 按顺序匹配
+	MentionAsPrefix bool     // 是否将提及机器人视为命令前缀
+}
+
+// ErrUnclosedQuote 表示命令中的引号没有闭合.
+var ErrUnclosedQuote = errors.New("命令中的引号没有闭合")
+
+// Parse 从消息中解析命令, 消息不是命令时第二个返回值为 false.
+//
+// 参数:
+//   m: 要解析的消息
+//   selfID: 机器人自身的用户 ID, 用于检测提及机器人, MentionAsPrefix 为 false 时可为空
+func (p *CommandParser) Parse(m Message, selfID string) (Command, bool, error) {
+	cmd := Command{Message: m}
+	rest := m
+	if p.MentionAsPrefix && selfID != "" {
+		rest, cmd.Mentioned = m.StripLeadingMention(selfID)
+	}
+
+	// find the first text segment and strip the prefix from it
+	rest = rest.Copy()
+	i := 0
+	for i < len(rest) && (rest[i].Type == SegTypeReply || isBlankText(rest[i])) {
+		i++
+	}
+	if i == len(rest) || rest[i].Type != SegTypeText {
+		return Command{}, false, nil
+	}
+	text, _ := rest[i].Data.GetString("text")
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	for _, prefix := range p.Prefixes {
+		if prefix != "" && strings.HasPrefix(text, prefix) {
+			cmd.Prefix = prefix
+			text = strings.TrimPrefix(text, prefix)
+			break
+		}
+	}
+	if cmd.Prefix == "" && !cmd.Mentioned && (len(p.Prefixes) > 0 || p.MentionAsPrefix) {
+		return Command{}, false, nil
+	}
+	rest[i].Data.Set("text", text)
+
+	args, err := TokenizeMessage(rest[i:])
+	if err != nil {
+		return Command{}, false, err
+	}
+	if len(args) == 0 || !args[0].IsText() || args[0].Text == "" {
+		return Command{}, false, nil
+	}
+	cmd.Name = args[0].Text
+	cmd.Args = args[1:]
+	return cmd, true, nil
+}
+
+// TokenizeMessage 将消息拆分为参数列表.
+//
+// 纯文本以空白字符分隔, 支持使用单引号或双引号包围含有空白字符的参数, 以及在引号内外使用反斜杠转义;
+// 非文本消息段 (回复消息段除外) 各自成为一个参数, 回复消息段将被忽略.
+func TokenizeMessage(m Message) ([]CommandArg, error) {
+	args := make([]CommandArg, 0)
+	var current strings.Builder
+	inToken := false // whether current holds a (possibly empty, like "") text token
+	var quote rune   // the quote character we are inside, 0 if not quoted
+	escaped := false
+	flush := func() {
+		if inToken {
+			args = append(args, CommandArg{Text: current.String()})
+			current.Reset()
+			inToken = false
+		}
+	}
+
+	for _, s := range m {
+		if s.Type != SegTypeText {
+			if quote != 0 {
+				return nil, ErrUnclosedQuote // a quoted string can not span over non-text segments
+			}
+			flush()
+			if s.Type != SegTypeReply {
+				seg := s.Copy()
+				args = append(args, CommandArg{Segment: &seg})
+			}
+			continue
+		}
+
+		text, _ := s.Data.GetString("text")
+		for _, r := range text {
+			switch {
+			case escaped:
+				current.WriteRune(r)
+				escaped = false
+			case r == '\\':
+				inToken, escaped = true, true
+			case quote != 0 && r == quote:
+				quote = 0
+			case quote != 0:
+				current.WriteRune(r)
+			case r == '"' || r == '\'':
+				inToken, quote = true, r
+			case unicode.IsSpace(r):
+				flush()
+			default:
+				inToken = true
+				current.WriteRune(r)
+			}
+		}
+	}
+	if quote != 0 {
+		return nil, ErrUnclosedQuote
+	}
+	if escaped {
+		current.WriteRune('\\') // keep the trailing backslash as is
+	}
+	flush()
+	return args, nil
+}
+
+// TextArgs 获取所有参数的文本, 非文本参数渲染为替代表示.
+func (c *Command) TextArgs() []string {
+	texts := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if arg.IsText() {
+			texts[i] = arg.Text
+		} else {
+			texts[i] = renderDefaultAlt(*arg.Segment)
+		}
+	}
+	return texts
+}
+
+func errorCommandArg(name string, err error) error {
+	return fmt.Errorf("参数 `%v` 无效: %v", name, err)
+}
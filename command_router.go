@@ -0,0 +1,311 @@
+// 命令路由
+
+package libonebot
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CommandRouter 将解析出的命令分发到按名称注册的处理函数, 并将命令参数绑定到处理函数的参数结构体.
+//
+// 处理函数的签名必须为 `func(*Command) error` 或 `func(*Command, T) error`, 其中 T 为结构体类型.
+// T 的导出字段按声明顺序依次绑定命令参数, 字段的 cmd tag 格式为 `名称[,optional]`, `-` 表示跳过该字段,
+// 没有 optional 选项的字段为必需参数. 支持的字段类型包括:
+//   string, 整数, 浮点数, bool: 由纯文本参数转换
+//   Segment: 任意非文本参数
+//   MentionSegmentData, ImageSegmentData 等: 对应类型的非文本参数
+//   []string, []CommandArg, Message: 剩余的所有参数, 必须为最后一个字段
+type CommandRouter struct {
+	Parser CommandParser // 命令解析器
+
+	handlers     map[string]*commandHandler
+	handlersLock *sync.RWMutex
+}
+
+type commandHandler struct {
+	fn       reflect.Value
+	argsType reflect.Type // nil if the handler takes no args struct
+}
+
+// NewCommandRouter 创建一个新的 CommandRouter 对象.
+//
+// 参数:
+//   prefixes: 命令前缀, 如 `/`
+func NewCommandRouter(prefixes ...string) *CommandRouter {
+	return &CommandRouter{
+		Parser: CommandParser{
+			Prefixes: prefixes,
+		},
+		handlers:     make(map[string]*commandHandler),
+		handlersLock: &sync.RWMutex{},
+	}
+}
+
+var (
+	commandPtrType   = reflect.TypeOf(&Command{})
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	segmentType      = reflect.TypeOf(Segment{})
+	stringsType      = reflect.TypeOf([]string{})
+	commandArgsType  = reflect.TypeOf([]CommandArg{})
+	segmentDataTypes = map[reflect.Type]string{
+		reflect.TypeOf(MentionSegmentData{}):    SegTypeMention,
+		reflect.TypeOf(MentionAllSegmentData{}): SegTypeMentionAll,
+		reflect.TypeOf(ImageSegmentData{}):      SegTypeImage,
+		reflect.TypeOf(VoiceSegmentData{}):      SegTypeVoice,
+		reflect.TypeOf(AudioSegmentData{}):      SegTypeAudio,
+		reflect.TypeOf(VideoSegmentData{}):      SegTypeVideo,
+		reflect.TypeOf(FileSegmentData{}):       SegTypeFile,
+		reflect.TypeOf(LocationSegmentData{}):   SegTypeLocation,
+	}
+)
+
+// Handle 注册一个命令处理函数, 同一个处理函数可以注册多个名称 (别名).
+//
+// 处理函数签名不符合要求时将 panic.
+func (r *CommandRouter) Handle(name string, handler interface{}) {
+	if name == "" {
+		panic("命令名称不能为空")
+	}
+	fn := reflect.ValueOf(handler)
+	ft := fn.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() < 1 || ft.NumIn() > 2 || ft.In(0) != commandPtrType ||
+		ft.NumOut() != 1 || ft.Out(0) != errorType {
+		panic("命令处理函数签名必须为 func(*Command) error 或 func(*Command, T) error")
+	}
+	h := &commandHandler{fn: fn}
+	if ft.NumIn() == 2 {
+		if err := checkCommandArgsType(ft.In(1)); err != nil {
+			panic(err.Error())
+		}
+		h.argsType = ft.In(1)
+	}
+	r.handlersLock.Lock()
+	r.handlers[name] = h
+	r.handlersLock.Unlock()
+}
+
+// Dispatch 解析消息中的命令并调用对应的处理函数.
+//
+// 消息不是命令或命令没有注册时返回 false 和 nil; 否则返回 true, 以及参数绑定失败的错误或处理函数返回的错误.
+//
+// 参数:
+//   m: 要解析的消息
+//   selfID: 机器人自身的用户 ID, 用于检测提及机器人
+func (r *CommandRouter) Dispatch(m Message, selfID string) (bool, error) {
+	return r.dispatch(m, selfID, nil)
+}
+
+// DispatchEvent 解析消息事件中的命令并调用对应的处理函数, 机器人自身的用户 ID 从事件的 self 字段获取.
+//
+// 事件不是消息事件时返回 false 和 nil, 其它返回值同 Dispatch.
+func (r *CommandRouter) DispatchEvent(event AnyEvent) (bool, error) {
+	holder, ok := event.(messageEventHolder)
+	if !ok {
+		return false, nil
+	}
+	e := holder.messageEvent()
+	selfID := ""
+	if e.Self != nil {
+		selfID = e.Self.UserID
+	}
+	return r.dispatch(e.Message, selfID, event)
+}
+
+func (r *CommandRouter) dispatch(m Message, selfID string, event AnyEvent) (bool, error) {
+	cmd, ok, err := r.Parser.Parse(m, selfID)
+	if err != nil || !ok {
+		return false, err
+	}
+	r.handlersLock.RLock()
+	h, ok := r.handlers[cmd.Name]
+	r.handlersLock.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	cmd.Event = event
+
+	in := []reflect.Value{reflect.ValueOf(&cmd)}
+	if h.argsType != nil {
+		args := reflect.New(h.argsType)
+		if err := bindCommandArgs(cmd.Args, args.Elem()); err != nil {
+			return true, fmt.Errorf("命令 `%v` %v", cmd.Name, err)
+		}
+		in = append(in, args.Elem())
+	}
+	out := h.fn.Call(in)
+	if err, _ := out[0].Interface().(error); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func checkCommandArgsType(t reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("命令处理函数的参数类型必须为结构体")
+	}
+	restSeen := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _ := parseCommandTag(field); field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if restSeen {
+			return fmt.Errorf("接收剩余参数的字段必须为最后一个字段")
+		}
+		switch kind := field.Type.Kind(); {
+		case field.Type == stringsType || field.Type == commandArgsType || field.Type == messageType:
+			restSeen = true
+		case field.Type == segmentType:
+		case segmentDataTypes[field.Type] != "":
+		case kind == reflect.String || kind == reflect.Bool:
+		case kind >= reflect.Int && kind <= reflect.Uint64:
+		case kind == reflect.Float32 || kind == reflect.Float64:
+		default:
+			return fmt.Errorf("命令参数字段 `%v` 的类型 `%v` 不支持", field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+func bindCommandArgs(args []CommandArg, rv reflect.Value) error {
+	rt := rv.Type()
+	next := 0
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name, optional := parseCommandTag(field)
+		if name == "-" {
+			continue
+		}
+
+		// rest args
+		switch field.Type {
+		case stringsType, commandArgsType, messageType:
+			rest := args[next:]
+			next = len(args)
+			rv.Field(i).Set(restCommandArgs(rest, field.Type))
+			continue
+		}
+
+		if next >= len(args) {
+			if optional {
+				continue
+			}
+			return fmt.Errorf("缺少参数 `%v`", name)
+		}
+		val, err := commandArgValue(args[next], field.Type)
+		if err != nil {
+			return errorCommandArg(name, err)
+		}
+		rv.Field(i).Set(val)
+		next++
+	}
+	if next < len(args) {
+		return fmt.Errorf("参数过多, 需要 %v 个, 实际 %v 个", next, len(args))
+	}
+	return nil
+}
+
+func parseCommandTag(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("cmd")
+	if !ok {
+		return strings.ToLower(field.Name), false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	optional := false
+	for _, opt := range parts[1:] {
+		if opt == "optional" {
+			optional = true
+		}
+	}
+	return name, optional
+}
+
+func restCommandArgs(args []CommandArg, t reflect.Type) reflect.Value {
+	switch t {
+	case stringsType:
+		texts := (&Command{Args: args}).TextArgs()
+		return reflect.ValueOf(texts)
+	case commandArgsType:
+		return reflect.ValueOf(append([]CommandArg{}, args...))
+	default:
+		m := Message{}
+		for i, arg := range args {
+			if i > 0 {
+				m = append(m, TextSegment(" "))
+			}
+			if arg.IsText() {
+				m = append(m, TextSegment(arg.Text))
+			} else {
+				m = append(m, arg.Segment.Copy())
+			}
+		}
+		m.Reduce()
+		return reflect.ValueOf(m)
+	}
+}
+
+func commandArgValue(arg CommandArg, t reflect.Type) (reflect.Value, error) {
+	if t == segmentType {
+		if arg.IsText() {
+			return reflect.Value{}, fmt.Errorf("需要非文本消息段, 实际为文本 `%v`", arg.Text)
+		}
+		return reflect.ValueOf(arg.Segment.Copy()), nil
+	}
+	if segType, ok := segmentDataTypes[t]; ok {
+		if arg.IsText() {
+			return reflect.Value{}, fmt.Errorf("需要 `%v` 消息段, 实际为文本 `%v`", segType, arg.Text)
+		}
+		data := reflect.New(t)
+		if err := arg.Segment.decodeData(segType, data.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+		return data.Elem(), nil
+	}
+
+	if !arg.IsText() {
+		return reflect.Value{}, fmt.Errorf("需要文本, 实际为 `%v` 消息段", arg.Segment.Type)
+	}
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		rv.SetString(arg.Text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(arg.Text)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("`%v` 不是有效的布尔值", arg.Text)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(arg.Text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("`%v` 不是有效的整数", arg.Text)
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(arg.Text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("`%v` 不是有效的非负整数", arg.Text)
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(arg.Text, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("`%v` 不是有效的数字", arg.Text)
+		}
+		rv.SetFloat(f)
+	default:
+		return reflect.Value{}, fmt.Errorf("不支持的参数类型 `%v`", t)
+	}
+	return rv, nil
+}
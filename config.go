@@ -4,6 +4,8 @@ package libonebot
 type Config struct {
 	Heartbeat ConfigHeartbeat `mapstructure:"heartbeat"` // 心跳
 	Comm      ConfigComm      `mapstructure:"comm"`      // 通信方式
+	Metrics   ConfigMetrics   `mapstructure:"metrics"`   // 运行指标
+	Health    ConfigHealth    `mapstructure:"health"`    // 健康检查
 }
 
 // ConfigHeartbeat 配置心跳.
@@ -12,12 +14,30 @@ type ConfigHeartbeat struct {
 	Interval uint32 `mapstructure:"interval"` // 心跳间隔, 单位: 毫秒, 必须大于 0
 }
 
+// ConfigMetrics 配置运行指标 HTTP 服务器, 以 Prometheus 文本格式输出 OneBot.Metrics 中的指标.
+type ConfigMetrics struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否启用
+	Host    string `mapstructure:"host"`    // HTTP 服务器监听 IP
+	Port    uint16 `mapstructure:"port"`    // HTTP 服务器监听端口
+	Path    string `mapstructure:"path"`    // 指标路径, 为空时为 `/metrics`
+}
+
+// ConfigHealth 配置单独的健康检查 HTTP 服务器, 提供 `/healthz` 和 `/readyz` 端点.
+//
+// 也可以通过 HTTP 或 WebSocket 通信方式配置中的 health 在通信方式的 HTTP 服务器上提供这些端点.
+type ConfigHealth struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否启用
+	Host    string `mapstructure:"host"`    // HTTP 服务器监听 IP
+	Port    uint16 `mapstructure:"port"`    // HTTP 服务器监听端口
+}
+
 // ConfigComm 配置通信方式.
 type ConfigComm struct {
 	HTTP        []ConfigCommHTTP        `mapstructure:"http"`         // HTTP 通信方式
 	HTTPWebhook []ConfigCommHTTPWebhook `mapstructure:"http_webhook"` // HTTP Webhook 通信方式
 	WS          []ConfigCommWS          `mapstructure:"ws"`           // WebSocket 通信方式
 	WSReverse   []ConfigCommWSReverse   `mapstructure:"ws_reverse"`   // 反向 WebSocket 通信方式
+	V11         ConfigCommV11           `mapstructure:"v11"`          // OneBot 11 兼容通信方式
 }
 
 // ConfigCommHTTP 配置一个 HTTP 通信方式.
@@ -27,6 +47,7 @@ type ConfigCommHTTP struct {
 	AccessToken     string `mapstructure:"access_token"`      // 访问令牌
 	EventEnabled    bool   `mapstructure:"event_enabled"`     // 是否启用 get_latest_events 轮询动作
 	EventBufferSize uint32 `mapstructure:"event_buffer_size"` // 事件缓冲区大小, 超过该大小将会丢弃最旧的事件, 0 表示不限大小
+	Health          bool   `mapstructure:"health"`            // 是否在该 HTTP 服务器上提供 `/healthz` 和 `/readyz` 端点
 }
 
 // ConfigCommHTTPWebhook 配置一个 HTTP Webhook 通信方式.
@@ -41,6 +62,7 @@ type ConfigCommWS struct {
 	Host        string `mapstructure:"host"`         // WebSocket 服务器监听 IP
 	Port        uint16 `mapstructure:"port"`         // WebSocket 服务器监听端口
 	AccessToken string `mapstructure:"access_token"` // 访问令牌
+	Health      bool   `mapstructure:"health"`       // 是否在该 WebSocket 服务器上提供 `/healthz` 和 `/readyz` 端点
 }
 
 // ConfigCommWSReverse 配置一个反向 WebSocket 通信方式.
@@ -49,3 +71,21 @@ type ConfigCommWSReverse struct {
 	AccessToken       string `mapstructure:"access_token"`       // 访问令牌
 	ReconnectInterval uint32 `mapstructure:"reconnect_interval"` // 反向 WebSocket 重连间隔, 单位: 毫秒, 必须大于 0
 }
+
+// ConfigCommV11 配置 OneBot 11 兼容通信方式.
+//
+// 这些通信方式使用 OneBot 11 的动作和事件格式, 动作请求将被转换为 OneBot 12 动作交由动作处理器处理,
+// 推送的事件将被转换为 OneBot 11 事件, 无法转换的事件将被忽略.
+type ConfigCommV11 struct {
+	HTTP      []ConfigCommV11HTTP   `mapstructure:"http"`       // OneBot 11 HTTP 通信方式
+	WS        []ConfigCommWS        `mapstructure:"ws"`         // OneBot 11 正向 WebSocket 通信方式
+	WSReverse []ConfigCommWSReverse `mapstructure:"ws_reverse"` // OneBot 11 反向 WebSocket 通信方式 (Universal 客户端)
+}
+
+// ConfigCommV11HTTP 配置一个 OneBot 11 HTTP 通信方式.
+type ConfigCommV11HTTP struct {
+	Host        string `mapstructure:"host"`         // HTTP 服务器监听 IP
+	Port        uint16 `mapstructure:"port"`         // HTTP 服务器监听端口
+	AccessToken string `mapstructure:"access_token"` // 访问令牌
+	Health      bool   `mapstructure:"health"`       // 是否在该 HTTP 服务器上提供 `/healthz` 和 `/readyz` 端点
+}
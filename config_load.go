@@ -0,0 +1,662 @@
+// 配置加载
+
+package libonebot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigEnvPrefix 是 LoadConfig 使用的环境变量前缀.
+const DefaultConfigEnvPrefix = "ONEBOT"
+
+// DefaultConfig 返回写入新的配置文件时使用的默认配置: 启用间隔为 5000 毫秒的心跳, 并在 127.0.0.1:5700 上启用 HTTP 通信方式.
+func DefaultConfig() *Config {
+	config := configFieldDefaults()
+	config.Heartbeat.Enabled = true
+	config.Comm.HTTP = []ConfigCommHTTP{configItemDefaults["comm.http"].(ConfigCommHTTP)}
+	return config
+}
+
+// configFieldDefaults 返回配置文件中缺少字段时使用的默认值, 不启用任何通信方式和其它组件.
+func configFieldDefaults() *Config {
+	return &Config{
+		Heartbeat: ConfigHeartbeat{Interval: 5000},
+		Metrics:   ConfigMetrics{Host: "127.0.0.1", Port: 5710, Path: "/metrics"},
+		Health:    ConfigHealth{Host: "127.0.0.1", Port: 5711},
+	}
+}
+
+// configItemDefaults 是配置文件中通信方式列表的每一项缺少字段时使用的默认值, 键为列表的字段路径.
+//
+// OneBot 11 通信方式使用不同的默认端口, 以便与 OneBot 12 通信方式同时启用.
+var configItemDefaults = map[string]interface{}{
+	"comm.http":           ConfigCommHTTP{Host: "127.0.0.1", Port: 5700, EventEnabled: true},
+	"comm.http_webhook":   ConfigCommHTTPWebhook{Timeout: 5000},
+	"comm.ws":             ConfigCommWS{Host: "127.0.0.1", Port: 6700},
+	"comm.ws_reverse":     ConfigCommWSReverse{ReconnectInterval: 5000},
+	"comm.v11.http":       ConfigCommV11HTTP{Host: "127.0.0.1", Port: 5701},
+	"comm.v11.ws":         ConfigCommWS{Host: "127.0.0.1", Port: 6701},
+	"comm.v11.ws_reverse": ConfigCommWSReverse{ReconnectInterval: 5000},
+}
+
+// configComments 是生成默认配置文件时各字段的注释, 键为结构体类型和 mapstructure 标签中的名称.
+var configComments = map[reflect.Type]map[string]string{
+	reflect.TypeOf(Config{}): {
+		"heartbeat": "心跳",
+		"comm":      "通信方式",
+		"metrics":   "运行指标 HTTP 服务器",
+		"health":    "健康检查 HTTP 服务器",
+	},
+	reflect.TypeOf(ConfigHeartbeat{}): {
+		"enabled":  "是否启用",
+		"interval": "心跳间隔, 单位: 毫秒, 必须大于 0",
+	},
+	reflect.TypeOf(ConfigMetrics{}): {
+		"enabled": "是否启用",
+		"host":    "HTTP 服务器监听 IP",
+		"port":    "HTTP 服务器监听端口",
+		"path":    "指标路径",
+	},
+	reflect.TypeOf(ConfigHealth{}): {
+		"enabled": "是否启用",
+		"host":    "HTTP 服务器监听 IP",
+		"port":    "HTTP 服务器监听端口",
+	},
+	reflect.TypeOf(ConfigComm{}): {
+		"http":         "HTTP 通信方式",
+		"http_webhook": "HTTP Webhook 通信方式",
+		"ws":           "WebSocket 通信方式",
+		"ws_reverse":   "反向 WebSocket 通信方式",
+		"v11":          "OneBot 11 兼容通信方式",
+	},
+	reflect.TypeOf(ConfigCommHTTP{}): {
+		"host":              "HTTP 服务器监听 IP",
+		"port":              "HTTP 服务器监听端口",
+		"access_token":      "访问令牌",
+		"event_enabled":     "是否启用 get_latest_events 轮询动作",
+		"event_buffer_size": "事件缓冲区大小, 超过该大小将会丢弃最旧的事件, 0 表示不限大小",
+		"health":            "是否在该 HTTP 服务器上提供 /healthz 和 /readyz 端点",
+	},
+	reflect.TypeOf(ConfigCommHTTPWebhook{}): {
+		"url":          "Webhook 上报地址",
+		"access_token": "访问令牌",
+		"timeout":      "上报请求超时时间, 单位: 毫秒, 0 表示不超时",
+	},
+	reflect.TypeOf(ConfigCommWS{}): {
+		"host":         "WebSocket 服务器监听 IP",
+		"port":         "WebSocket 服务器监听端口",
+		"access_token": "访问令牌",
+		"health":       "是否在该 WebSocket 服务器上提供 /healthz 和 /readyz 端点",
+	},
+	reflect.TypeOf(ConfigCommWSReverse{}): {
+		"url":                "反向 WebSocket 连接地址",
+		"access_token":       "访问令牌",
+		"reconnect_interval": "反向 WebSocket 重连间隔, 单位: 毫秒, 必须大于 0",
+	},
+	reflect.TypeOf(ConfigCommV11{}): {
+		"http":       "OneBot 11 HTTP 通信方式",
+		"ws":         "OneBot 11 正向 WebSocket 通信方式",
+		"ws_reverse": "OneBot 11 反向 WebSocket 通信方式 (Universal 客户端)",
+	},
+	reflect.TypeOf(ConfigCommV11HTTP{}): {
+		"host":         "HTTP 服务器监听 IP",
+		"port":         "HTTP 服务器监听端口",
+		"access_token": "访问令牌",
+		"health":       "是否在该 HTTP 服务器上提供 /healthz 和 /readyz 端点",
+	},
+}
+
+// ConfigLoader 从配置文件和环境变量加载配置.
+type ConfigLoader struct {
+	EnvPrefix    string // 环境变量前缀, 为空时不读取环境变量
+	WriteDefault bool   // 配置文件不存在时是否写入带注释的默认配置文件
+}
+
+// NewConfigLoader 创建一个新的 ConfigLoader 对象, 使用 DefaultConfigEnvPrefix 作为环境变量前缀, 并在配置文件不存在时写入默认配置文件.
+func NewConfigLoader() *ConfigLoader {
+	return &ConfigLoader{
+		EnvPrefix:    DefaultConfigEnvPrefix,
+		WriteDefault: true,
+	}
+}
+
+// LoadConfig 使用默认的 ConfigLoader 加载配置, 见 ConfigLoader.Load.
+func LoadConfig(path string, config interface{}) error {
+	return NewConfigLoader().Load(path, config)
+}
+
+// Load 从配置文件和环境变量加载配置.
+//
+// 配置文件格式由扩展名决定, 支持 `.toml`, `.yaml`, `.yml` 和 `.json`.
+// 配置文件中缺少的字段使用默认值, 通信方式列表中的每一项缺少的字段也使用该通信方式的默认值.
+// 环境变量的优先级高于配置文件, 名称由前缀和大写的字段路径组成, 列表使用下标, 如 `ONEBOT_COMM_HTTP_0_PORT`,
+// 下标超出配置文件中的列表长度时将添加新的项.
+//
+// 配置文件不存在时, 若 WriteDefault 为 true, 将写入带注释的默认配置文件 (见 DefaultConfig) 并按其加载, 否则只使用默认值和环境变量.
+// 除写入默认配置文件的情况外, 配置文件中缺少的通信方式和心跳等组件不会被启用.
+// 配置文件中存在未知的配置项时返回 *ConfigError.
+//
+// 参数:
+//   path: 配置文件路径
+//   config: 指向 Config 或嵌入了 Config 的结构体的指针, 其中为零值的 Config 的字段使用默认值, 其他字段的当前值作为默认值
+func (l *ConfigLoader) Load(path string, config interface{}) error {
+	target := reflect.ValueOf(config)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config 必须是指向结构体的指针")
+	}
+	format, err := configFormat(path)
+	if err != nil {
+		return err
+	}
+
+	var raw interface{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		m := make(map[string]interface{})
+		if err := unmarshalConfig(format, data, &m); err != nil {
+			return fmt.Errorf("配置文件 %v 解析失败, 错误: %v", path, err)
+		}
+		raw = m
+	case os.IsNotExist(err):
+	default:
+		return fmt.Errorf("配置文件 %v 读取失败, 错误: %v", path, err)
+	}
+
+	// only a newly generated config file enables components by default
+	generate := raw == nil && l.WriteDefault
+	if generate {
+		setDefaultConfig(target.Elem(), DefaultConfig())
+	} else {
+		setDefaultConfig(target.Elem(), configFieldDefaults())
+	}
+	defaults := configValueToMap(target.Elem())
+	if generate {
+		if err := writeDefaultConfig(path, format, target.Elem().Type(), defaults, l.EnvPrefix); err != nil {
+			return fmt.Errorf("默认配置文件 %v 写入失败, 错误: %v", path, err)
+		}
+	}
+
+	r := &configResolver{}
+	if l.EnvPrefix != "" {
+		r.env = make(map[string]string)
+		for _, kv := range os.Environ() {
+			if i := strings.IndexByte(kv, '='); i > 0 && strings.HasPrefix(kv[:i], l.EnvPrefix+"_") {
+				r.env[kv[:i]] = kv[i+1:]
+			}
+		}
+	}
+	merged := r.resolve(target.Elem().Type(), defaults, raw, raw != nil, "", l.EnvPrefix)
+	if len(r.unknown) > 0 {
+		return &ConfigError{Errors: r.unknown}
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           config,
+		WeaklyTypedInput: true,
+		Squash:           true,
+		ZeroFields:       true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(merged); err != nil {
+		return fmt.Errorf("配置 %v 加载失败, 错误: %v", path, err)
+	}
+	return nil
+}
+
+func configFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		return "toml", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	case ".json":
+		return "json", nil
+	default:
+		return "", fmt.Errorf("不支持的配置文件格式 `%v`", ext)
+	}
+}
+
+func unmarshalConfig(format string, data []byte, m *map[string]interface{}) error {
+	switch format {
+	case "toml":
+		return toml.Unmarshal(data, m)
+	case "yaml":
+		return yaml.Unmarshal(data, m)
+	default:
+		return json.Unmarshal(data, m)
+	}
+}
+
+// setDefaultConfig 将 v 本身或其中嵌入的零值 Config 替换为 def.
+func setDefaultConfig(v reflect.Value, def *Config) {
+	if v.Type() == reflect.TypeOf(Config{}) {
+		if v.IsZero() {
+			v.Set(reflect.ValueOf(*def))
+		}
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && v.Field(i).CanSet() {
+			setDefaultConfig(v.Field(i), def)
+		}
+	}
+}
+
+type configField struct {
+	name  string // mapstructure 标签中的名称, 没有标签时为字段名
+	index []int
+	typ   reflect.Type
+}
+
+// configFields 按 mapstructure 的规则列出结构体的字段, 嵌入的结构体将被展开.
+func configFields(t reflect.Type) []configField {
+	fields := []configField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Type.Kind() == reflect.Struct && (f.Anonymous || strings.Contains(tag, ",squash")) {
+			for _, sub := range configFields(f.Type) {
+				sub.index = append([]int{i}, sub.index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, configField{name: name, index: []int{i}, typ: f.Type})
+	}
+	return fields
+}
+
+// isConfigTable 判断类型是否作为嵌套的配置表处理, 没有可导出字段的结构体 (如 time.Time) 作为单个值处理.
+func isConfigTable(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && len(configFields(t)) > 0
+}
+
+func isConfigTableList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && isConfigTable(t.Elem())
+}
+
+// configValueToMap 将配置值转换为以 mapstructure 标签为键的 map.
+func configValueToMap(v reflect.Value) interface{} {
+	switch {
+	case isConfigTable(v.Type()):
+		m := make(map[string]interface{})
+		for _, f := range configFields(v.Type()) {
+			m[f.name] = configValueToMap(v.FieldByIndex(f.index))
+		}
+		return m
+	case isConfigTableList(v.Type()):
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = configValueToMap(v.Index(i))
+		}
+		return list
+	default:
+		return v.Interface()
+	}
+}
+
+// configItemDefault 返回字段路径为 path, 元素类型为 t 的列表中每一项的默认值.
+func configItemDefault(path string, t reflect.Type) map[string]interface{} {
+	item, ok := configItemDefaults[path]
+	if !ok || reflect.TypeOf(item) != t {
+		return configValueToMap(reflect.New(t).Elem()).(map[string]interface{})
+	}
+	return configValueToMap(reflect.ValueOf(item)).(map[string]interface{})
+}
+
+type configResolver struct {
+	env     map[string]string // 以前缀开头的环境变量
+	unknown []ConfigFieldError
+}
+
+// resolve 按类型 t 合并默认值, 配置文件中的值和环境变量, 返回交给 mapstructure 解码的值.
+//
+// 参数:
+//   t: 配置值的类型
+//   def: 默认值
+//   raw: 配置文件中的值
+//   hasRaw: 配置文件中是否存在该值
+//   path: 字段路径, 用于错误信息
+//   envKey: 对应的环境变量名称
+func (r *configResolver) resolve(t reflect.Type, def interface{}, raw interface{}, hasRaw bool, path string, envKey string) interface{} {
+	hasRaw = hasRaw && raw != nil // empty values in YAML and JSON null fall back to defaults
+	switch {
+	case isConfigTable(t):
+		defMap, _ := def.(map[string]interface{})
+		rawMap, ok := toConfigMap(raw)
+		if hasRaw && !ok {
+			return raw // let mapstructure report the type error
+		}
+		used := make(map[string]bool)
+		m := make(map[string]interface{})
+		for _, f := range configFields(t) {
+			key, value, found := lookupConfigKey(rawMap, f.name)
+			if found {
+				used[key] = true
+			}
+			m[f.name] = r.resolve(f.typ, defMap[f.name], value, found, joinConfigPath(path, f.name), envKey+"_"+strings.ToUpper(f.name))
+		}
+		for key := range rawMap {
+			if !used[key] {
+				r.unknown = append(r.unknown, ConfigFieldError{Path: joinConfigPath(path, key), Message: "是未知的配置项"})
+			}
+		}
+		return m
+	case isConfigTableList(t):
+		items, _ := def.([]interface{})
+		if hasRaw {
+			rawItems, ok := toConfigList(raw)
+			if !ok {
+				return raw
+			}
+			items = rawItems
+		}
+		n := r.envListLen(envKey)
+		if n < len(items) {
+			n = len(items)
+		}
+		itemDef := configItemDefault(path, t.Elem())
+		list := make([]interface{}, n)
+		for i := range list {
+			itemPath, itemEnvKey := fmt.Sprintf("%v[%d]", path, i), fmt.Sprintf("%v_%d", envKey, i)
+			switch {
+			case i >= len(items):
+				list[i] = r.resolve(t.Elem(), itemDef, nil, false, itemPath, itemEnvKey)
+			case hasRaw:
+				list[i] = r.resolve(t.Elem(), itemDef, items[i], true, itemPath, itemEnvKey)
+			default:
+				list[i] = r.resolve(t.Elem(), items[i], nil, false, itemPath, itemEnvKey)
+			}
+		}
+		return list
+	default:
+		if value, ok := r.env[envKey]; ok {
+			return value
+		}
+		if hasRaw {
+			return raw
+		}
+		return def
+	}
+}
+
+// envListLen 返回环境变量中以 envKey 为前缀的列表的最大下标加一.
+func (r *configResolver) envListLen(envKey string) int {
+	n := 0
+	for key := range r.env {
+		if !strings.HasPrefix(key, envKey+"_") {
+			continue
+		}
+		rest := key[len(envKey)+1:]
+		if i := strings.IndexByte(rest, '_'); i > 0 {
+			if index, err := strconv.Atoi(rest[:i]); err == nil && index >= n {
+				n = index + 1
+			}
+		}
+	}
+	return n
+}
+
+func joinConfigPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// lookupConfigKey 在配置文件的 map 中查找字段, 与 mapstructure 一致, 找不到完全匹配的键时忽略大小写.
+func lookupConfigKey(m map[string]interface{}, name string) (string, interface{}, bool) {
+	if value, ok := m[name]; ok {
+		return name, value, true
+	}
+	for key, value := range m {
+		if strings.EqualFold(key, name) {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+func toConfigMap(v interface{}) (map[string]interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+func toConfigList(v interface{}) ([]interface{}, bool) {
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// writeDefaultConfig 写入带注释的默认配置文件, JSON 格式不支持注释.
+func writeDefaultConfig(path string, format string, t reflect.Type, defaults interface{}, envPrefix string) error {
+	var lines []string
+	switch format {
+	case "toml", "yaml":
+		lines = append(lines, "# OneBot 配置")
+		if envPrefix != "" {
+			lines = append(lines, fmt.Sprintf("# 每一项都可以通过环境变量覆盖, 如 %v_COMM_HTTP_0_PORT", envPrefix))
+		}
+		if format == "toml" {
+			lines = append(lines, tomlConfigLines(t, defaults.(map[string]interface{}), "")...)
+		} else {
+			lines = append(lines, "")
+			lines = append(lines, yamlConfigLines(t, defaults.(map[string]interface{}), "")...)
+		}
+	default:
+		lines = jsonConfigLines(t, defaults)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+}
+
+func configComment(t reflect.Type, name string) []string {
+	if comment, ok := configComments[t][name]; ok {
+		return []string{"# " + comment}
+	}
+	return nil
+}
+
+func commentOut(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			out[i] = line
+		} else {
+			out[i] = "# " + line
+		}
+	}
+	return out
+}
+
+func tomlConfigValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func isNilConfigValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// tomlConfigLines 生成 TOML 表的内容, 单个值在前, 嵌套的表和表数组在后.
+func tomlConfigLines(t reflect.Type, m map[string]interface{}, table string) []string {
+	var lines, tables []string
+	for _, f := range configFields(t) {
+		name := joinConfigPath(table, f.name)
+		switch {
+		case isConfigTable(f.typ):
+			tables = append(tables, "")
+			tables = append(tables, configComment(t, f.name)...)
+			tables = append(tables, "["+name+"]")
+			tables = append(tables, tomlConfigLines(f.typ, m[f.name].(map[string]interface{}), name)...)
+		case isConfigTableList(f.typ):
+			items := m[f.name].([]interface{})
+			tables = append(tables, "")
+			tables = append(tables, configComment(t, f.name)...)
+			if len(items) == 0 {
+				item := append([]string{"[[" + name + "]]"}, tomlConfigLines(f.typ.Elem(), configItemDefault(name, f.typ.Elem()), name)...)
+				tables = append(tables, commentOut(item)...)
+			}
+			for i, item := range items {
+				if i > 0 {
+					tables = append(tables, "")
+				}
+				tables = append(tables, "[["+name+"]]")
+				tables = append(tables, tomlConfigLines(f.typ.Elem(), item.(map[string]interface{}), name)...)
+			}
+		case isNilConfigValue(m[f.name]):
+			// TOML has no null
+		default:
+			lines = append(lines, configComment(t, f.name)...)
+			lines = append(lines, f.name+" = "+tomlConfigValue(m[f.name]))
+		}
+	}
+	return append(lines, tables...)
+}
+
+func yamlConfigLines(t reflect.Type, m map[string]interface{}, path string) []string {
+	var lines []string
+	for _, f := range configFields(t) {
+		name := joinConfigPath(path, f.name)
+		if isConfigTable(f.typ) && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, configComment(t, f.name)...)
+		switch {
+		case isConfigTable(f.typ):
+			lines = append(lines, f.name+":")
+			lines = append(lines, indentConfigLines(yamlConfigLines(f.typ, m[f.name].(map[string]interface{}), name), "  ", "  ")...)
+		case isConfigTableList(f.typ):
+			items := m[f.name].([]interface{})
+			if len(items) == 0 {
+				lines = append(lines, f.name+": []")
+				item := indentConfigLines(yamlConfigLines(f.typ.Elem(), configItemDefault(name, f.typ.Elem()), name), "  - ", "    ")
+				lines = append(lines, commentOut(append([]string{f.name + ":"}, item...))...)
+				continue
+			}
+			lines = append(lines, f.name+":")
+			for _, item := range items {
+				lines = append(lines, indentConfigLines(yamlConfigLines(f.typ.Elem(), item.(map[string]interface{}), name), "  - ", "    ")...)
+			}
+		default:
+			b, _ := json.Marshal(m[f.name])
+			lines = append(lines, f.name+": "+string(b))
+		}
+	}
+	return lines
+}
+
+// indentConfigLines 缩进 YAML 行, 第一个非注释行使用 first 作为前缀, 其余行使用 rest.
+func indentConfigLines(lines []string, first string, rest string) []string {
+	out := make([]string, len(lines))
+	firstDone := false
+	for i, line := range lines {
+		if !firstDone && !strings.HasPrefix(line, "#") {
+			out[i] = first + line
+			firstDone = true
+		} else {
+			out[i] = rest + line
+		}
+	}
+	return out
+}
+
+// jsonConfigLines 按字段声明顺序生成 JSON.
+func jsonConfigLines(t reflect.Type, v interface{}) []string {
+	switch {
+	case isConfigTable(t):
+		m := v.(map[string]interface{})
+		fields := configFields(t)
+		lines := []string{"{"}
+		for i, f := range fields {
+			value := jsonConfigLines(f.typ, m[f.name])
+			key, _ := json.Marshal(f.name)
+			value[0] = string(key) + ": " + value[0]
+			if i < len(fields)-1 {
+				value[len(value)-1] += ","
+			}
+			lines = append(lines, indentConfigLines(value, "  ", "  ")...)
+		}
+		return append(lines, "}")
+	case isConfigTableList(t):
+		items := v.([]interface{})
+		if len(items) == 0 {
+			return []string{"[]"}
+		}
+		lines := []string{"["}
+		for i, item := range items {
+			value := jsonConfigLines(t.Elem(), item)
+			if i < len(items)-1 {
+				value[len(value)-1] += ","
+			}
+			lines = append(lines, indentConfigLines(value, "  ", "  ")...)
+		}
+		return append(lines, "]")
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		return []string{strings.TrimSuffix(buf.String(), "\n")}
+	}
+}
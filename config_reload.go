@@ -0,0 +1,168 @@
+// 配置热重载
+
+package libonebot
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+)
+
+// componentStopTimeout 是 ApplyConfig 停止单个组件时等待正在处理的请求完成的最长时间, 超时后强制关闭.
+const componentStopTimeout = 10 * time.Second
+
+// ApplyConfig 应用新的配置.
+//
+// OneBot 实例运行中时, 只停止被移除的组件 (通信方式, 心跳, 运行指标和健康检查 HTTP 服务器), 启动新增的组件,
+// 并重启配置发生改变的组件, 配置未改变的通信方式上的连接不受影响; 未运行时只替换 OneBot.Config, 下次启动时生效.
+// 每个被停止的组件最多等待 componentStopTimeout (10 秒) 让正在处理的请求完成, 超时后强制关闭.
+// 新配置无效时返回 *ConfigError, 不做任何改变; 部分组件启动失败时返回 *StartError,
+// 并停止本次启动的组件, 以原配置重新启动被停止的组件, 恢复原来的 OneBot.Config.
+// 动作处理器等其它 goroutine 应通过 GetConfig 读取当前配置, 以免与配置替换发生数据竞争.
+func (ob *OneBot) ApplyConfig(config *Config) error {
+	if err := checkConfig(config); err != nil {
+		return err
+	}
+
+	// serialize config changes, stopping components below is bounded by componentStopTimeout
+	ob.configLock.Lock()
+	defer ob.configLock.Unlock()
+
+	oldConfig := ob.GetConfig()
+	ob.setConfig(config)
+	ctx := ob.runCtx
+	if ctx == nil || ctx.Err() != nil {
+		return nil
+	}
+
+	starters := ob.commStarters(config)
+	desired := make(map[string]interface{}, len(starters))
+	for _, s := range starters {
+		desired[s.name] = s.config
+	}
+
+	ob.commsLock.Lock()
+	if ob.comms == nil {
+		ob.commsLock.Unlock()
+		return nil
+	}
+	running := make(map[string]bool, len(ob.comms))
+	stopping := make([]*commHandle, 0)
+	for name, h := range ob.comms {
+		if c, ok := desired[name]; ok && reflect.DeepEqual(c, h.config) {
+			running[name] = true
+			continue
+		}
+		stopping = append(stopping, h)
+		delete(ob.comms, name)
+	}
+	ob.commsLock.Unlock()
+
+	// stop first so that changed listeners can rebind the same address
+	ob.stopHandles(stopping)
+
+	started := make([]*commHandle, 0)
+	errs := make([]error, 0)
+	for _, s := range starters {
+		if running[s.name] {
+			continue
+		}
+		h, err := ob.startComm(ctx, s)
+		if err != nil {
+			ob.Logger.Errorf("%v", err)
+			errs = append(errs, err)
+			continue
+		}
+		started = append(started, h)
+	}
+	if len(errs) == 0 {
+		ob.Logger.Infof("配置已应用, 停止 %v 个组件, 启动 %v 个组件", len(stopping), len(started))
+		return nil
+	}
+
+	// roll back to the previous config
+	ob.Logger.Warnf("部分组件启动失败, 正在恢复原配置...")
+	ob.commsLock.Lock()
+	for _, h := range started {
+		delete(ob.comms, h.name)
+	}
+	ob.commsLock.Unlock()
+	ob.stopHandles(started)
+	for _, h := range stopping {
+		if _, err := ob.startComm(ctx, h.starter); err != nil {
+			ob.Logger.Errorf("%v 以原配置重新启动失败, 错误: %v", h.name, err)
+			errs = append(errs, err)
+		}
+	}
+	ob.setConfig(oldConfig)
+	return &StartError{Errors: errs}
+}
+
+// stopHandles 停止已从 OneBot.comms 中移除的组件, 每个组件最多等待 componentStopTimeout.
+func (ob *OneBot) stopHandles(handles []*commHandle) {
+	for _, h := range handles {
+		ob.Logger.Infof("正在停止 %v...", h.name)
+		drainCtx, cancel := context.WithTimeout(context.Background(), componentStopTimeout)
+		defer cancel()
+		h.drainCtx = drainCtx
+		h.cancel()
+	}
+	for _, h := range handles {
+		if !waitGroupContext(h.wg, h.drainCtx) {
+			ob.Logger.Warnf("%v 停止超时, 已强制关闭", h.name)
+		}
+	}
+}
+
+// WatchConfig 定期检查配置文件, 文件被修改时重新加载配置并通过 ApplyConfig 应用, 直到 ctx 被取消.
+//
+// 该方法会阻塞当前线程, 加载或应用配置失败时只记录日志, 继续使用原来的配置.
+//
+// 参数:
+//   ctx: 取消时停止检查
+//   path: 配置文件路径
+//   interval: 检查间隔, 通过比较文件的修改时间和大小判断文件是否被修改
+//   load: 加载配置的函数, 为 nil 时使用不写入默认配置文件的 ConfigLoader 加载到新的 Config 中, 配置文件中包含扩展字段时需要提供该函数
+func (ob *OneBot) WatchConfig(ctx context.Context, path string, interval time.Duration, load func(path string) (*Config, error)) {
+	if load == nil {
+		load = func(path string) (*Config, error) {
+			config := &Config{}
+			loader := &ConfigLoader{EnvPrefix: DefaultConfigEnvPrefix}
+			return config, loader.Load(path, config)
+		}
+	}
+
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ob.Logger.Infof("开始监视配置文件 %v", path)
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				ob.Logger.Debugf("配置文件 %v 读取失败, 错误: %v", path, err)
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+
+			ob.Logger.Infof("配置文件 %v 已修改, 正在重新加载...", path)
+			config, err := load(path)
+			if err != nil {
+				ob.Logger.Errorf("配置文件 %v 加载失败, 错误: %v", path, err)
+				continue
+			}
+			if err := ob.ApplyConfig(config); err != nil {
+				ob.Logger.Errorf("配置应用失败, 错误: %v", err)
+			}
+		case <-ctx.Done():
+			ob.Logger.Infof("停止监视配置文件 %v", path)
+			return
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package libonebot
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ConfigFieldError 表示配置中一个字段的错误.
+type ConfigFieldError struct {
+	Path    string // 字段路径, 由 mapstructure 标签组成, 如 `comm.ws_reverse[1].reconnect_interval`
+	Message string // 错误信息
+}
+
+func (e ConfigFieldError) Error() string {
+	return fmt.Sprintf("`%v` %v", e.Path, e.Message)
+}
+
+// ConfigError 表示配置无效, 包含所有字段的错误.
+type ConfigError struct {
+	Errors []ConfigFieldError
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "配置无效: " + strings.Join(msgs, "; ")
+}
+
+type configValidator struct {
+	errors    []ConfigFieldError
+	listeners []configListener
+}
+
+type configListener struct {
+	path string
+	host string
+	port uint16
+}
+
+func (v *configValidator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ConfigFieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate 检查配置, 返回 *ConfigError, 包含所有发现的问题, 配置有效时返回 nil.
+//
+// 检查的内容包括心跳间隔, 监听端口, 重复的监听地址, URL 格式, 反向 WebSocket 重连间隔,
+// 以及监听非本机地址的通信方式是否设置了访问令牌.
+func (c *Config) Validate() error {
+	v := &configValidator{}
+
+	if c.Heartbeat.Enabled && c.Heartbeat.Interval == 0 {
+		v.fail("heartbeat.interval", "必须大于 0")
+	}
+
+	for i, h := range c.Comm.HTTP {
+		v.validateListener(fmt.Sprintf("comm.http[%d]", i), h.Host, h.Port, h.AccessToken, true)
+	}
+	for i, w := range c.Comm.HTTPWebhook {
+		v.validateURL(fmt.Sprintf("comm.http_webhook[%d].url", i), w.URL, "http", "https")
+	}
+	for i, w := range c.Comm.WS {
+		v.validateListener(fmt.Sprintf("comm.ws[%d]", i), w.Host, w.Port, w.AccessToken, true)
+	}
+	for i, w := range c.Comm.WSReverse {
+		v.validateWSReverse(fmt.Sprintf("comm.ws_reverse[%d]", i), w)
+	}
+	for i, h := range c.Comm.V11.HTTP {
+		v.validateListener(fmt.Sprintf("comm.v11.http[%d]", i), h.Host, h.Port, h.AccessToken, true)
+	}
+	for i, w := range c.Comm.V11.WS {
+		v.validateListener(fmt.Sprintf("comm.v11.ws[%d]", i), w.Host, w.Port, w.AccessToken, true)
+	}
+	for i, w := range c.Comm.V11.WSReverse {
+		v.validateWSReverse(fmt.Sprintf("comm.v11.ws_reverse[%d]", i), w)
+	}
+
+	if c.Metrics.Enabled {
+		v.validateListener("metrics", c.Metrics.Host, c.Metrics.Port, "", false)
+		if c.Metrics.Path != "" && !strings.HasPrefix(c.Metrics.Path, "/") {
+			v.fail("metrics.path", "必须以 `/` 开头")
+		}
+	}
+	if c.Health.Enabled {
+		v.validateListener("health", c.Health.Host, c.Health.Port, "", false)
+	}
+
+	if len(v.errors) > 0 {
+		return &ConfigError{Errors: v.errors}
+	}
+	return nil
+}
+
+func (v *configValidator) validateListener(path string, host string, port uint16, accessToken string, needToken bool) {
+	if port == 0 {
+		v.fail(path+".port", "必须大于 0")
+		return
+	}
+	for _, l := range v.listeners {
+		if l.port == port && (l.host == host || isWildcardHost(l.host) || isWildcardHost(host)) {
+			v.fail(path+".port", "与 `%v` 的监听地址冲突", l.path)
+			break
+		}
+	}
+	v.listeners = append(v.listeners, configListener{path, host, port})
+	if needToken && accessToken == "" && !isLoopbackHost(host) {
+		v.fail(path+".access_token", "监听非本机地址时不能为空")
+	}
+}
+
+func (v *configValidator) validateURL(path string, rawURL string, schemes ...string) {
+	if rawURL == "" {
+		v.fail(path, "不能为空")
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		v.fail(path, "不是合法的 URL: %v", err)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			if u.Host == "" {
+				v.fail(path, "缺少主机名")
+			}
+			return
+		}
+	}
+	v.fail(path, "必须使用 %v 协议", strings.ToUpper(strings.Join(schemes, " 或 ")))
+}
+
+func (v *configValidator) validateWSReverse(path string, c ConfigCommWSReverse) {
+	v.validateURL(path+".url", c.URL, "ws", "wss")
+	if c.ReconnectInterval == 0 {
+		v.fail(path+".reconnect_interval", "必须大于 0")
+	}
+}
+
+func isWildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,307 @@
+// 分片上传/获取文件
+// https://12.onebot.dev/interface/file/actions/#upload_file_fragmented
+
+package libonebot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxFileFragmentSize 是分片上传/获取文件时单个分片的最大大小, 单位: 字节.
+const MaxFileFragmentSize = 16 * 1024 * 1024
+
+// FragmentedFileManager 管理分片上传文件的会话, 并基于 FileStorage 提供分片获取文件的能力.
+//
+// 分片上传时, 各分片可以乱序到达, 在结束阶段会校验文件完整大小和 SHA256 校验和,
+// 校验通过后将文件存入 FileStorage. 超过指定时间没有活动的上传会话将被丢弃.
+//
+// FragmentedFileManager 实现了 UploadFileFragmentedImpl 和 GetFileFragmentedImpl 接口,
+// 可通过 ActionMux.HandleImplementation 注册.
+type FragmentedFileManager struct {
+	storage      FileStorage
+	timeout      time.Duration
+	sessions     map[string]*uploadSession
+	sessionsLock *sync.Mutex
+}
+
+type uploadSession struct {
+	name       string
+	totalSize  int64
+	file       *os.File
+	fragments  []fileFragment
+	lastActive time.Time
+	discarded  bool // 会话已结束, 临时文件已删除
+	lock       *sync.Mutex
+}
+
+type fileFragment struct {
+	offset int64
+	size   int64
+}
+
+// NewFragmentedFileManager 创建一个新的 FragmentedFileManager 对象.
+//
+// 参数:
+//   storage: 文件存储, 不能为 nil
+//   timeout: 上传会话超时时间, 超过该时间没有收到分片的会话将被丢弃, 0 表示不超时
+func NewFragmentedFileManager(storage FileStorage, timeout time.Duration) *FragmentedFileManager {
+	if storage == nil {
+		panic("必须提供文件存储")
+	}
+	return &FragmentedFileManager{
+		storage:      storage,
+		timeout:      timeout,
+		sessions:     make(map[string]*uploadSession),
+		sessionsLock: &sync.Mutex{},
+	}
+}
+
+// UploadFileFragmented 为 FragmentedFileManager 实现 UploadFileFragmentedImpl 接口.
+func (m *FragmentedFileManager) UploadFileFragmented(r *Request, params UploadFileFragmentedParams) (interface{}, error) {
+	m.CleanExpired()
+
+	switch params.Stage {
+	case FragmentedStagePrepare:
+		fileID, err := m.prepareUpload(params.Name, params.TotalSize)
+		if err != nil {
+			return nil, NewActionError(RetCodeFilesystemError, err)
+		}
+		return UploadFileResponse{FileID: fileID}, nil
+	case FragmentedStageTransfer:
+		if retCode, err := m.transferUpload(params.FileID, params.Offset, params.Data); err != nil {
+			return nil, NewActionError(retCode, err)
+		}
+		return nil, nil
+	case FragmentedStageFinish:
+		storedFileID, retCode, err := m.finishUpload(params.FileID, params.SHA256)
+		if err != nil {
+			return nil, NewActionError(retCode, err)
+		}
+		return UploadFileResponse{FileID: storedFileID}, nil
+	default:
+		return nil, NewActionError(RetCodeBadParam, errors.New("`stage` 参数值无效"))
+	}
+}
+
+// GetFileFragmented 为 FragmentedFileManager 实现 GetFileFragmentedImpl 接口.
+func (m *FragmentedFileManager) GetFileFragmented(r *Request, params GetFileFragmentedParams) (interface{}, error) {
+	switch params.Stage {
+	case FragmentedStagePrepare:
+		info, reader, err := m.storage.OpenFile(params.FileID)
+		if err != nil {
+			return nil, NewActionError(retCodeFromStorageError(err), err)
+		}
+		reader.Close()
+		return GetFileFragmentedPrepareResponse{
+			Name:      info.Name,
+			TotalSize: info.TotalSize,
+			SHA256:    info.SHA256,
+		}, nil
+	case FragmentedStageTransfer:
+		data, retCode, err := m.readFragment(params.FileID, params.Offset, params.Size)
+		if err != nil {
+			return nil, NewActionError(retCode, err)
+		}
+		return GetFileFragmentedTransferResponse{Data: data}, nil
+	default:
+		return nil, NewActionError(RetCodeBadParam, errors.New("`stage` 参数值无效"))
+	}
+}
+
+// CleanExpired 丢弃所有已超时的上传会话.
+//
+// 每次处理分片上传请求时都会自动调用, 通常不需要手动调用.
+func (m *FragmentedFileManager) CleanExpired() {
+	if m.timeout == 0 {
+		return
+	}
+	now := time.Now()
+	m.sessionsLock.Lock()
+	defer m.sessionsLock.Unlock()
+	for fileID, session := range m.sessions {
+		session.lock.Lock()
+		expired := now.Sub(session.lastActive) > m.timeout
+		if expired {
+			session.discard()
+			delete(m.sessions, fileID)
+		}
+		session.lock.Unlock()
+	}
+}
+
+// Close 丢弃所有未完成的上传会话.
+func (m *FragmentedFileManager) Close() {
+	m.sessionsLock.Lock()
+	defer m.sessionsLock.Unlock()
+	for fileID, session := range m.sessions {
+		session.lock.Lock()
+		session.discard()
+		session.lock.Unlock()
+		delete(m.sessions, fileID)
+	}
+}
+
+func (m *FragmentedFileManager) prepareUpload(name string, totalSize int64) (string, error) {
+	if totalSize < 0 {
+		return "", errors.New("`total_size` 参数值无效")
+	}
+	file, err := ioutil.TempFile("", "libonebot-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败, 错误: %v", err)
+	}
+	fileID := uuid.New().String()
+	m.sessionsLock.Lock()
+	m.sessions[fileID] = &uploadSession{
+		name:       name,
+		totalSize:  totalSize,
+		file:       file,
+		fragments:  make([]fileFragment, 0),
+		lastActive: time.Now(),
+		lock:       &sync.Mutex{},
+	}
+	m.sessionsLock.Unlock()
+	return fileID, nil
+}
+
+func (m *FragmentedFileManager) getSession(fileID string) (*uploadSession, error) {
+	m.sessionsLock.Lock()
+	defer m.sessionsLock.Unlock()
+	session, ok := m.sessions[fileID]
+	if !ok {
+		return nil, fmt.Errorf("上传会话 `%v` 不存在或已超时", fileID)
+	}
+	return session, nil
+}
+
+func (m *FragmentedFileManager) transferUpload(fileID string, offset int64, data []byte) (int, error) {
+	session, err := m.getSession(fileID)
+	if err != nil {
+		return RetCodeLogicError, err
+	}
+	if len(data) > MaxFileFragmentSize {
+		return RetCodeBadParam, fmt.Errorf("分片大小超过上限 %v 字节", MaxFileFragmentSize)
+	}
+
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if session.discarded {
+		// finished or expired after we got it
+		return RetCodeLogicError, fmt.Errorf("上传会话 `%v` 不存在或已超时", fileID)
+	}
+	if offset < 0 || int64(len(data)) > session.totalSize-offset {
+		return RetCodeBadParam, errors.New("分片超出文件范围")
+	}
+	if _, err := session.file.WriteAt(data, offset); err != nil {
+		return RetCodeFilesystemError, fmt.Errorf("写入临时文件失败, 错误: %v", err)
+	}
+	session.fragments = append(session.fragments, fileFragment{offset, int64(len(data))})
+	session.lastActive = time.Now()
+	return RetCodeOK, nil
+}
+
+func (m *FragmentedFileManager) finishUpload(fileID string, sha256Hex string) (string, int, error) {
+	m.sessionsLock.Lock()
+	session, ok := m.sessions[fileID]
+	delete(m.sessions, fileID) // the session ends here whether or not it succeeds
+	m.sessionsLock.Unlock()
+	if !ok {
+		return "", RetCodeLogicError, fmt.Errorf("上传会话 `%v` 不存在或已超时", fileID)
+	}
+
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	defer session.discard()
+
+	if !session.isComplete() {
+		return "", RetCodeLogicError, errors.New("文件分片不完整")
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(session.file, 0, session.totalSize)); err != nil {
+		return "", RetCodeFilesystemError, fmt.Errorf("读取临时文件失败, 错误: %v", err)
+	}
+	actualSHA256 := hex.EncodeToString(hash.Sum(nil))
+	if actualSHA256 != strings.ToLower(sha256Hex) {
+		return "", RetCodeLogicError, errors.New("文件 SHA256 校验和不匹配")
+	}
+
+	info := FileInfo{
+		Name:      session.name,
+		TotalSize: session.totalSize,
+		SHA256:    actualSHA256,
+	}
+	storedFileID, err := m.storage.StoreFile(info, io.NewSectionReader(session.file, 0, session.totalSize))
+	if err != nil {
+		return "", RetCodeFilesystemError, fmt.Errorf("存储文件失败, 错误: %v", err)
+	}
+	return storedFileID, RetCodeOK, nil
+}
+
+func (m *FragmentedFileManager) readFragment(fileID string, offset int64, size int64) ([]byte, int, error) {
+	info, reader, err := m.storage.OpenFile(fileID)
+	if err != nil {
+		return nil, retCodeFromStorageError(err), err
+	}
+	defer reader.Close()
+
+	if offset < 0 || size < 0 || offset > info.TotalSize {
+		return nil, RetCodeBadParam, errors.New("分片超出文件范围")
+	}
+	if size > MaxFileFragmentSize {
+		return nil, RetCodeBadParam, fmt.Errorf("分片大小超过上限 %v 字节", MaxFileFragmentSize)
+	}
+	if size > info.TotalSize-offset {
+		size = info.TotalSize - offset
+	}
+	data := make([]byte, size)
+	n, err := reader.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, RetCodeFilesystemError, fmt.Errorf("读取文件失败, 错误: %v", err)
+	}
+	return data[:n], RetCodeOK, nil
+}
+
+func (s *uploadSession) isComplete() bool {
+	sort.Slice(s.fragments, func(i, j int) bool {
+		return s.fragments[i].offset < s.fragments[j].offset
+	})
+	covered := int64(0)
+	for _, f := range s.fragments {
+		if f.offset > covered {
+			return false // there is a hole
+		}
+		if f.offset+f.size > covered {
+			covered = f.offset + f.size
+		}
+	}
+	return covered == s.totalSize
+}
+
+// discard 删除会话的临时文件, 调用时必须持有 s.lock.
+func (s *uploadSession) discard() {
+	if s.discarded {
+		return
+	}
+	s.discarded = true
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+func retCodeFromStorageError(err error) int {
+	if err == ErrFileNotFound {
+		return RetCodeLogicError
+	}
+	return RetCodeFilesystemError
+}
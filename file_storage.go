@@ -0,0 +1,91 @@
+// 文件存储
+
+package libonebot
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrFileNotFound 表示要获取的文件不存在.
+var ErrFileNotFound = errors.New("文件不存在")
+
+// FileInfo 表示一个已存储文件的元信息.
+type FileInfo struct {
+	Name      string // 文件名
+	TotalSize int64  // 文件完整大小, 单位: 字节
+	SHA256    string // 文件数据 (所有字节) 的 SHA256 校验和, 全小写十六进制
+}
+
+// FileReader 表示一个可随机读取的已存储文件.
+type FileReader interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// FileStorage 是文件存储需要实现的接口, OneBot 实现可根据需要将文件存储在本地或机器人平台.
+type FileStorage interface {
+	// StoreFile 存储一个文件, 返回文件 ID.
+	//
+	// data 中恰好包含 info.TotalSize 字节的数据, 且其 SHA256 校验和已经过验证.
+	StoreFile(info FileInfo, data io.Reader) (string, error)
+
+	// OpenFile 打开一个已存储的文件, 文件不存在时应返回 ErrFileNotFound.
+	OpenFile(fileID string) (FileInfo, FileReader, error)
+}
+
+// MemoryFileStorage 是将文件保存在内存中的 FileStorage 实现, 适用于测试或文件较少的场景.
+type MemoryFileStorage struct {
+	files     map[string]memoryFile
+	filesLock *sync.RWMutex
+}
+
+type memoryFile struct {
+	info FileInfo
+	data []byte
+}
+
+type memoryFileReader struct {
+	*bytes.Reader
+}
+
+func (r memoryFileReader) Close() error {
+	return nil
+}
+
+// NewMemoryFileStorage 创建一个新的 MemoryFileStorage 对象.
+func NewMemoryFileStorage() *MemoryFileStorage {
+	return &MemoryFileStorage{
+		files:     make(map[string]memoryFile),
+		filesLock: &sync.RWMutex{},
+	}
+}
+
+// StoreFile 为 MemoryFileStorage 实现 FileStorage 接口.
+func (s *MemoryFileStorage) StoreFile(info FileInfo, data io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	fileID := uuid.New().String()
+	s.filesLock.Lock()
+	s.files[fileID] = memoryFile{info: info, data: b}
+	s.filesLock.Unlock()
+	return fileID, nil
+}
+
+// OpenFile 为 MemoryFileStorage 实现 FileStorage 接口.
+func (s *MemoryFileStorage) OpenFile(fileID string) (FileInfo, FileReader, error) {
+	s.filesLock.RLock()
+	defer s.filesLock.RUnlock()
+	f, ok := s.files[fileID]
+	if !ok {
+		return FileInfo{}, nil, ErrFileNotFound
+	}
+	return f.info, memoryFileReader{bytes.NewReader(f.data)}, nil
+}
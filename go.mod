@@ -3,6 +3,7 @@ module github.com/botuniverse/go-libonebot
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/websocket v1.5.0
 	github.com/mitchellh/mapstructure v1.5.0
@@ -11,4 +12,5 @@ require (
 	github.com/tidwall/gjson v1.14.1
 	github.com/vmihailenco/msgpack/v5 v5.3.5
 	golang.org/x/sys v0.0.0-20220627191245-f75cf1eec38b // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,180 @@
+// 健康检查
+
+package libonebot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ComponentHealth 表示 OneBot 实例中一个组件 (如通信方式) 的就绪状态.
+type ComponentHealth struct {
+	Ready bool   `json:"ready"`           // 是否就绪
+	Error string `json:"error,omitempty"` // 未就绪的原因
+}
+
+// ReadinessReport 表示 OneBot 实例的就绪状态.
+type ReadinessReport struct {
+	Ready      bool                       `json:"ready"`              // 是否就绪, 所有组件和机器人平台都就绪时为 true
+	Components map[string]ComponentHealth `json:"components"`         // 各组件的就绪状态, 键为组件名称, 如 `http 127.0.0.1:5700`
+	Platform   *ComponentHealth           `json:"platform,omitempty"` // 机器人平台的就绪状态, 未设置 OneBot.ReadinessCheck 时为 nil
+}
+
+type healthState struct {
+	running    bool
+	components map[string]ComponentHealth
+	lock       *sync.RWMutex
+}
+
+func newHealthState() *healthState {
+	return &healthState{
+		components: make(map[string]ComponentHealth),
+		lock:       &sync.RWMutex{},
+	}
+}
+
+func (h *healthState) setRunning(running bool) {
+	h.lock.Lock()
+	h.running = running
+	h.lock.Unlock()
+}
+
+func (h *healthState) set(component string, err error) {
+	status := ComponentHealth{Ready: err == nil}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	h.lock.Lock()
+	h.components[component] = status
+	h.lock.Unlock()
+}
+
+func (h *healthState) remove(component string) {
+	h.lock.Lock()
+	delete(h.components, component)
+	h.lock.Unlock()
+}
+
+// componentName 返回通信方式在就绪状态中的组件名称.
+func componentName(method int, addr string) string {
+	return commMethodLabels[method] + " " + addr
+}
+
+var errNotConnected = errors.New("未连接")
+
+// CheckReadiness 检查 OneBot 实例是否就绪.
+//
+// OneBot 实例运行中, 所有通信方式都启动成功 (反向 WebSocket 已连接), 且 ReadinessCheck 没有返回错误时, 才视为就绪.
+func (ob *OneBot) CheckReadiness(ctx context.Context) ReadinessReport {
+	ob.health.lock.RLock()
+	report := ReadinessReport{
+		Ready:      ob.health.running,
+		Components: make(map[string]ComponentHealth, len(ob.health.components)),
+	}
+	for name, status := range ob.health.components {
+		report.Components[name] = status
+		report.Ready = report.Ready && status.Ready
+	}
+	ob.health.lock.RUnlock()
+
+	if ob.ReadinessCheck != nil {
+		platform := ComponentHealth{Ready: true}
+		if err := ob.ReadinessCheck(ctx); err != nil {
+			platform = ComponentHealth{Ready: false, Error: err.Error()}
+		}
+		report.Platform = &platform
+		report.Ready = report.Ready && platform.Ready
+	}
+	return report
+}
+
+// HealthHandler 返回提供 `/healthz` (存活检查) 和 `/readyz` (就绪检查) 端点的 HTTP 处理器,
+// 可挂载到 OneBot 实现自己的 HTTP 服务器上.
+//
+// 存活检查总是返回 200; 就绪检查在就绪时返回 200, 否则返回 503, 响应体为 JSON 格式的 ReadinessReport.
+func (ob *OneBot) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/healthz"):
+			w.Write([]byte(`{"status":"ok"}`))
+		case strings.HasSuffix(r.URL.Path, "/readyz"):
+			report := ob.CheckReadiness(r.Context())
+			if !report.Ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			json.NewEncoder(w).Encode(report)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// handleHealth 在通信方式的 HTTP 服务器上注册健康检查端点.
+func (ob *OneBot) handleHealth(mux *http.ServeMux) {
+	handler := ob.HealthHandler()
+	mux.Handle("/healthz", handler)
+	mux.Handle("/readyz", handler)
+}
+
+// listenAndServe 同步监听地址, 成功后在后台运行 HTTP 服务器, 监听结果记录到组件的就绪状态中.
+//
+// 参数:
+//   server: 要运行的 HTTP 服务器
+//   component: 组件名称
+//   name: 日志中的服务器名称
+//   wg: 运行 HTTP 服务器的 goroutine 将加入其中
+func (ob *OneBot) listenAndServe(server *http.Server, component string, name string, wg *sync.WaitGroup) error {
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		ob.health.set(component, err)
+		return fmt.Errorf("%v 启动失败, 错误: %v", name, err)
+	}
+	ob.health.set(component, nil)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			err := fmt.Errorf("%v 运行失败, 错误: %v", name, err)
+			ob.Logger.Errorf("%v", err)
+			ob.health.set(component, err)
+			ob.setRunError(err)
+		}
+	}()
+	return nil
+}
+
+func commStartHealth(c ConfigHealth, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	ob.Logger.Infof("正在启动健康检查 HTTP 服务器 (%v)...", addr)
+
+	mux := http.NewServeMux()
+	ob.handleHealth(mux)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	component := "health " + addr
+	if err := ob.listenAndServe(server, component, fmt.Sprintf("健康检查 HTTP 服务器 (%v)", addr), wg); err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ob.health.remove(component)
+
+		<-ctx.Done()
+		if err := ob.shutdownServer(ctx, server); err != nil {
+			ob.Logger.Errorf("健康检查 HTTP 服务器 (%v) 关闭失败, 错误: %v", addr, err)
+		}
+		ob.Logger.Infof("健康检查 HTTP 服务器 (%v) 已关闭", addr)
+	}()
+	return nil
+}
@@ -11,3 +11,155 @@ const (
 	ActionGetFile              = "get_file"               // 获取文件
 	ActionGetFileFragmented    = "get_file_fragmented"    // 分片获取文件
 )
+
+// FragmentedStageXxx 表示分片上传/获取文件动作的阶段.
+const (
+	FragmentedStagePrepare  = "prepare"  // 准备阶段
+	FragmentedStageTransfer = "transfer" // 传输阶段
+	FragmentedStageFinish   = "finish"   // 结束阶段 (仅分片上传)
+)
+
+// 文件动作响应数据
+
+// UploadFileResponse 表示上传文件结果 (upload_file 动作响应数据, 以及 upload_file_fragmented 动作准备和结束阶段的响应数据).
+type UploadFileResponse struct {
+	FileID   string         `json:"file_id"` // 文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// MarshalJSON 将 UploadFileResponse 编码为 JSON, 扩展字段与标准字段平铺.
+func (d UploadFileResponse) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 UploadFileResponse 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d UploadFileResponse) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// GetFileResponse 表示获取文件结果 (get_file 动作响应数据), 根据请求的 `type` 参数填写 URL, Path 或 Data 中的一个.
+type GetFileResponse struct {
+	Name     string            `json:"name"`              // 文件名
+	URL      string            `json:"url,omitempty"`     // 文件 URL
+	Headers  map[string]string `json:"headers,omitempty"` // 下载 URL 时需要添加的 HTTP 请求头
+	Path     string            `json:"path,omitempty"`    // 文件路径
+	Data     []byte            `json:"data,omitempty"`    // 文件数据
+	SHA256   string            `json:"sha256,omitempty"`  // 文件数据 (原始二进制) 的 SHA256 校验和, 全小写, 可不填
+	Extended ExtendedFields    `json:"-"`                 // 扩展字段
+}
+
+// MarshalJSON 将 GetFileResponse 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GetFileResponse) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GetFileResponse 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GetFileResponse) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// GetFileFragmentedPrepareResponse 表示分片获取文件准备阶段结果 (get_file_fragmented 动作准备阶段的响应数据).
+type GetFileFragmentedPrepareResponse struct {
+	Name      string         `json:"name"`       // 文件名
+	TotalSize int64          `json:"total_size"` // 文件完整大小, 单位: 字节
+	SHA256    string         `json:"sha256"`     // 整个文件的 SHA256 校验和, 全小写
+	Extended  ExtendedFields `json:"-"`          // 扩展字段
+}
+
+// MarshalJSON 将 GetFileFragmentedPrepareResponse 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GetFileFragmentedPrepareResponse) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GetFileFragmentedPrepareResponse 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GetFileFragmentedPrepareResponse) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// GetFileFragmentedTransferResponse 表示分片获取文件传输阶段结果 (get_file_fragmented 动作传输阶段的响应数据).
+type GetFileFragmentedTransferResponse struct {
+	Data     []byte         `json:"data"` // 本次传输的文件数据
+	Extended ExtendedFields `json:"-"`    // 扩展字段
+}
+
+// MarshalJSON 将 GetFileFragmentedTransferResponse 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GetFileFragmentedTransferResponse) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GetFileFragmentedTransferResponse 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GetFileFragmentedTransferResponse) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// UploadFileParams 表示 upload_file 动作的请求参数.
+type UploadFileParams struct {
+	Type     string            `json:"type"`              // 上传文件的方式, 可以为 url, path, data 或扩展的方式
+	Name     string            `json:"name"`              // 文件名
+	URL      string            `json:"url,omitempty"`     // 文件 URL, 当 type 为 url 时必须传入
+	Headers  map[string]string `json:"headers,omitempty"` // 下载 URL 时需要添加的 HTTP 请求头, 可选传入
+	Path     string            `json:"path,omitempty"`    // 文件路径, 当 type 为 path 时必须传入
+	Data     []byte            `json:"data,omitempty"`    // 文件数据, 当 type 为 data 时必须传入
+	SHA256   string            `json:"sha256,omitempty"`  // 文件数据 (原始二进制) 的 SHA256 校验和, 全小写, 可选传入
+	Extended ExtendedFields    `json:"-"`                 // 扩展参数
+}
+
+// UploadFileFragmentedParams 表示 upload_file_fragmented 动作的请求参数.
+type UploadFileFragmentedParams struct {
+	Stage     string         `json:"stage"`                // 上传阶段, 可以为 prepare, transfer 或 finish
+	Name      string         `json:"name,omitempty"`       // 文件名, 准备阶段必须传入
+	TotalSize int64          `json:"total_size,omitempty"` // 文件完整大小, 准备阶段必须传入
+	FileID    string         `json:"file_id,omitempty"`    // 准备阶段返回的文件 ID, 传输和结束阶段必须传入
+	Offset    int64          `json:"offset,omitempty"`     // 本次传输的文件偏移, 单位: 字节, 传输阶段必须传入
+	Data      []byte         `json:"data,omitempty"`       // 本次传输的文件数据, 传输阶段必须传入
+	SHA256    string         `json:"sha256,omitempty"`     // 整个文件的 SHA256 校验和, 全小写, 结束阶段必须传入
+	Extended  ExtendedFields `json:"-"`                    // 扩展参数
+}
+
+// GetFileParams 表示 get_file 动作的请求参数.
+type GetFileParams struct {
+	FileID   string         `json:"file_id"` // 文件 ID
+	Type     string         `json:"type"`    // 获取文件的方式, 可以为 url, path, data 或扩展的方式
+	Extended ExtendedFields `json:"-"`       // 扩展参数
+}
+
+// GetFileFragmentedParams 表示 get_file_fragmented 动作的请求参数.
+type GetFileFragmentedParams struct {
+	Stage    string         `json:"stage"`            // 获取阶段, 可以为 prepare 或 transfer
+	FileID   string         `json:"file_id"`          // 文件 ID
+	Offset   int64          `json:"offset,omitempty"` // 本次获取的文件偏移, 单位: 字节, 传输阶段必须传入
+	Size     int64          `json:"size,omitempty"`   // 本次获取的数据大小, 单位: 字节, 传输阶段必须传入
+	Extended ExtendedFields `json:"-"`                // 扩展参数
+}
+
+// 动作实现接口
+
+// UploadFileImpl 表示实现了 upload_file 动作 (上传文件) 的对象.
+type UploadFileImpl interface {
+	UploadFile(r *Request, params UploadFileParams) (UploadFileResponse, error)
+}
+
+// UploadFileFragmentedImpl 表示实现了 upload_file_fragmented 动作 (分片上传文件) 的对象.
+type UploadFileFragmentedImpl interface {
+	UploadFileFragmented(r *Request, params UploadFileFragmentedParams) (interface{}, error)
+}
+
+// GetFileImpl 表示实现了 get_file 动作 (获取文件) 的对象.
+type GetFileImpl interface {
+	GetFile(r *Request, params GetFileParams) (GetFileResponse, error)
+}
+
+// GetFileFragmentedImpl 表示实现了 get_file_fragmented 动作 (分片获取文件) 的对象.
+type GetFileFragmentedImpl interface {
+	GetFileFragmented(r *Request, params GetFileFragmentedParams) (interface{}, error)
+}
+
+// FileImpl 表示实现了文件接口所有动作的对象.
+type FileImpl interface {
+	UploadFileImpl
+	UploadFileFragmentedImpl
+	GetFileImpl
+	GetFileFragmentedImpl
+}
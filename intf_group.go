@@ -108,3 +108,121 @@ const (
 	ActionSetGroupName       = "set_group_name"        // 设置群名称
 	ActionLeaveGroup         = "leave_group"           // 退出群
 )
+
+// 群动作响应数据
+
+// GroupInfo 表示群信息 (get_group_info 动作响应数据, 以及 get_group_list 动作响应数据的列表项).
+type GroupInfo struct {
+	GroupID   string         `json:"group_id"`   // 群 ID
+	GroupName string         `json:"group_name"` // 群名称
+	Extended  ExtendedFields `json:"-"`          // 扩展字段
+}
+
+// MarshalJSON 将 GroupInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GroupInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GroupInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GroupInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// GroupMemberInfo 表示群成员信息 (get_group_member_info 动作响应数据, 以及 get_group_member_list 动作响应数据的列表项).
+type GroupMemberInfo struct {
+	UserID          string         `json:"user_id"`          // 用户 ID
+	UserName        string         `json:"user_name"`        // 用户名称/昵称
+	UserDisplayname string         `json:"user_displayname"` // 用户设置的显示名称, 可为空
+	Extended        ExtendedFields `json:"-"`                // 扩展字段
+}
+
+// MarshalJSON 将 GroupMemberInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GroupMemberInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GroupMemberInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GroupMemberInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// GetGroupInfoParams 表示 get_group_info 动作的请求参数.
+type GetGroupInfoParams struct {
+	GroupID  string         `json:"group_id"` // 群 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// GetGroupListParams 表示 get_group_list 动作的请求参数.
+type GetGroupListParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// GetGroupMemberInfoParams 表示 get_group_member_info 动作的请求参数.
+type GetGroupMemberInfoParams struct {
+	GroupID  string         `json:"group_id"` // 群 ID
+	UserID   string         `json:"user_id"`  // 用户 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// GetGroupMemberListParams 表示 get_group_member_list 动作的请求参数.
+type GetGroupMemberListParams struct {
+	GroupID  string         `json:"group_id"` // 群 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// SetGroupNameParams 表示 set_group_name 动作的请求参数.
+type SetGroupNameParams struct {
+	GroupID   string         `json:"group_id"`   // 群 ID
+	GroupName string         `json:"group_name"` // 新群名称
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// LeaveGroupParams 表示 leave_group 动作的请求参数.
+type LeaveGroupParams struct {
+	GroupID  string         `json:"group_id"` // 群 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// 动作实现接口
+
+// GetGroupInfoImpl 表示实现了 get_group_info 动作 (获取群信息) 的对象.
+type GetGroupInfoImpl interface {
+	GetGroupInfo(r *Request, params GetGroupInfoParams) (GroupInfo, error)
+}
+
+// GetGroupListImpl 表示实现了 get_group_list 动作 (获取群列表) 的对象.
+type GetGroupListImpl interface {
+	GetGroupList(r *Request, params GetGroupListParams) ([]GroupInfo, error)
+}
+
+// GetGroupMemberInfoImpl 表示实现了 get_group_member_info 动作 (获取群成员信息) 的对象.
+type GetGroupMemberInfoImpl interface {
+	GetGroupMemberInfo(r *Request, params GetGroupMemberInfoParams) (GroupMemberInfo, error)
+}
+
+// GetGroupMemberListImpl 表示实现了 get_group_member_list 动作 (获取群成员列表) 的对象.
+type GetGroupMemberListImpl interface {
+	GetGroupMemberList(r *Request, params GetGroupMemberListParams) ([]GroupMemberInfo, error)
+}
+
+// SetGroupNameImpl 表示实现了 set_group_name 动作 (设置群名称) 的对象.
+type SetGroupNameImpl interface {
+	SetGroupName(r *Request, params SetGroupNameParams) error
+}
+
+// LeaveGroupImpl 表示实现了 leave_group 动作 (退出群) 的对象.
+type LeaveGroupImpl interface {
+	LeaveGroup(r *Request, params LeaveGroupParams) error
+}
+
+// GroupImpl 表示实现了单级群组接口所有动作的对象.
+type GroupImpl interface {
+	GetGroupInfoImpl
+	GetGroupListImpl
+	GetGroupMemberInfoImpl
+	GetGroupMemberListImpl
+	SetGroupNameImpl
+	LeaveGroupImpl
+}
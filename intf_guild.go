@@ -204,3 +204,236 @@ const (
 	ActionGetChannelMemberList = "get_channel_member_list" // 获取频道成员列表
 	ActionLeaveChannel         = "leave_channel"           // 退出频道
 )
+
+// 群组动作响应数据
+
+// GuildInfo 表示群组信息 (get_guild_info 动作响应数据, 以及 get_guild_list 动作响应数据的列表项).
+type GuildInfo struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	GuildName string         `json:"guild_name"` // 群组名称
+	Extended  ExtendedFields `json:"-"`          // 扩展字段
+}
+
+// MarshalJSON 将 GuildInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GuildInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GuildInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GuildInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// GuildMemberInfo 表示群组成员信息 (get_guild_member_info 动作响应数据, 以及 get_guild_member_list 动作响应数据的列表项).
+type GuildMemberInfo struct {
+	UserID          string         `json:"user_id"`          // 用户 ID
+	UserName        string         `json:"user_name"`        // 用户名称/昵称
+	UserDisplayname string         `json:"user_displayname"` // 用户设置的显示名称, 可为空
+	Extended        ExtendedFields `json:"-"`                // 扩展字段
+}
+
+// MarshalJSON 将 GuildMemberInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d GuildMemberInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 GuildMemberInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d GuildMemberInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// ChannelInfo 表示频道信息 (get_channel_info 动作响应数据, 以及 get_channel_list 动作响应数据的列表项).
+type ChannelInfo struct {
+	ChannelID   string         `json:"channel_id"`   // 频道 ID
+	ChannelName string         `json:"channel_name"` // 频道名称
+	Extended    ExtendedFields `json:"-"`            // 扩展字段
+}
+
+// MarshalJSON 将 ChannelInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d ChannelInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 ChannelInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d ChannelInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// ChannelMemberInfo 表示频道成员信息 (get_channel_member_info 动作响应数据, 以及 get_channel_member_list 动作响应数据的列表项).
+type ChannelMemberInfo struct {
+	UserID          string         `json:"user_id"`          // 用户 ID
+	UserName        string         `json:"user_name"`        // 用户名称/昵称
+	UserDisplayname string         `json:"user_displayname"` // 用户设置的显示名称, 可为空
+	Extended        ExtendedFields `json:"-"`                // 扩展字段
+}
+
+// MarshalJSON 将 ChannelMemberInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d ChannelMemberInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 ChannelMemberInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d ChannelMemberInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// GetGuildInfoParams 表示 get_guild_info 动作的请求参数.
+type GetGuildInfoParams struct {
+	GuildID  string         `json:"guild_id"` // 群组 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// GetGuildListParams 表示 get_guild_list 动作的请求参数.
+type GetGuildListParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// SetGuildNameParams 表示 set_guild_name 动作的请求参数.
+type SetGuildNameParams struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	GuildName string         `json:"guild_name"` // 新群组名称
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// GetGuildMemberInfoParams 表示 get_guild_member_info 动作的请求参数.
+type GetGuildMemberInfoParams struct {
+	GuildID  string         `json:"guild_id"` // 群组 ID
+	UserID   string         `json:"user_id"`  // 用户 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// GetGuildMemberListParams 表示 get_guild_member_list 动作的请求参数.
+type GetGuildMemberListParams struct {
+	GuildID  string         `json:"guild_id"` // 群组 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// LeaveGuildParams 表示 leave_guild 动作的请求参数.
+type LeaveGuildParams struct {
+	GuildID  string         `json:"guild_id"` // 群组 ID
+	Extended ExtendedFields `json:"-"`        // 扩展参数
+}
+
+// GetChannelInfoParams 表示 get_channel_info 动作的请求参数.
+type GetChannelInfoParams struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	ChannelID string         `json:"channel_id"` // 频道 ID
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// GetChannelListParams 表示 get_channel_list 动作的请求参数.
+type GetChannelListParams struct {
+	GuildID    string         `json:"guild_id"`              // 群组 ID
+	JoinedOnly bool           `json:"joined_only,omitempty"` // 只获取机器人账号已加入的频道列表
+	Extended   ExtendedFields `json:"-"`                     // 扩展参数
+}
+
+// SetChannelNameParams 表示 set_channel_name 动作的请求参数.
+type SetChannelNameParams struct {
+	GuildID     string         `json:"guild_id"`     // 群组 ID
+	ChannelID   string         `json:"channel_id"`   // 频道 ID
+	ChannelName string         `json:"channel_name"` // 新频道名称
+	Extended    ExtendedFields `json:"-"`            // 扩展参数
+}
+
+// GetChannelMemberInfoParams 表示 get_channel_member_info 动作的请求参数.
+type GetChannelMemberInfoParams struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	ChannelID string         `json:"channel_id"` // 频道 ID
+	UserID    string         `json:"user_id"`    // 用户 ID
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// GetChannelMemberListParams 表示 get_channel_member_list 动作的请求参数.
+type GetChannelMemberListParams struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	ChannelID string         `json:"channel_id"` // 频道 ID
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// LeaveChannelParams 表示 leave_channel 动作的请求参数.
+type LeaveChannelParams struct {
+	GuildID   string         `json:"guild_id"`   // 群组 ID
+	ChannelID string         `json:"channel_id"` // 频道 ID
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// 动作实现接口
+
+// GetGuildInfoImpl 表示实现了 get_guild_info 动作 (获取群组信息) 的对象.
+type GetGuildInfoImpl interface {
+	GetGuildInfo(r *Request, params GetGuildInfoParams) (GuildInfo, error)
+}
+
+// GetGuildListImpl 表示实现了 get_guild_list 动作 (获取群组列表) 的对象.
+type GetGuildListImpl interface {
+	GetGuildList(r *Request, params GetGuildListParams) ([]GuildInfo, error)
+}
+
+// SetGuildNameImpl 表示实现了 set_guild_name 动作 (设置群组名称) 的对象.
+type SetGuildNameImpl interface {
+	SetGuildName(r *Request, params SetGuildNameParams) error
+}
+
+// GetGuildMemberInfoImpl 表示实现了 get_guild_member_info 动作 (获取群组成员信息) 的对象.
+type GetGuildMemberInfoImpl interface {
+	GetGuildMemberInfo(r *Request, params GetGuildMemberInfoParams) (GuildMemberInfo, error)
+}
+
+// GetGuildMemberListImpl 表示实现了 get_guild_member_list 动作 (获取群组成员列表) 的对象.
+type GetGuildMemberListImpl interface {
+	GetGuildMemberList(r *Request, params GetGuildMemberListParams) ([]GuildMemberInfo, error)
+}
+
+// LeaveGuildImpl 表示实现了 leave_guild 动作 (退出群组) 的对象.
+type LeaveGuildImpl interface {
+	LeaveGuild(r *Request, params LeaveGuildParams) error
+}
+
+// GetChannelInfoImpl 表示实现了 get_channel_info 动作 (获取频道信息) 的对象.
+type GetChannelInfoImpl interface {
+	GetChannelInfo(r *Request, params GetChannelInfoParams) (ChannelInfo, error)
+}
+
+// GetChannelListImpl 表示实现了 get_channel_list 动作 (获取频道列表) 的对象.
+type GetChannelListImpl interface {
+	GetChannelList(r *Request, params GetChannelListParams) ([]ChannelInfo, error)
+}
+
+// SetChannelNameImpl 表示实现了 set_channel_name 动作 (设置频道名称) 的对象.
+type SetChannelNameImpl interface {
+	SetChannelName(r *Request, params SetChannelNameParams) error
+}
+
+// GetChannelMemberInfoImpl 表示实现了 get_channel_member_info 动作 (获取频道成员信息) 的对象.
+type GetChannelMemberInfoImpl interface {
+	GetChannelMemberInfo(r *Request, params GetChannelMemberInfoParams) (ChannelMemberInfo, error)
+}
+
+// GetChannelMemberListImpl 表示实现了 get_channel_member_list 动作 (获取频道成员列表) 的对象.
+type GetChannelMemberListImpl interface {
+	GetChannelMemberList(r *Request, params GetChannelMemberListParams) ([]ChannelMemberInfo, error)
+}
+
+// LeaveChannelImpl 表示实现了 leave_channel 动作 (退出频道) 的对象.
+type LeaveChannelImpl interface {
+	LeaveChannel(r *Request, params LeaveChannelParams) error
+}
+
+// GuildImpl 表示实现了两级群组接口所有动作的对象.
+type GuildImpl interface {
+	GetGuildInfoImpl
+	GetGuildListImpl
+	SetGuildNameImpl
+	GetGuildMemberInfoImpl
+	GetGuildMemberListImpl
+	LeaveGuildImpl
+	GetChannelInfoImpl
+	GetChannelListImpl
+	SetChannelNameImpl
+	GetChannelMemberInfoImpl
+	GetChannelMemberListImpl
+	LeaveChannelImpl
+}
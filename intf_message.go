@@ -2,6 +2,12 @@
 
 package libonebot
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // 消息段
 // https://12.onebot.dev/interface/message/segments/
 
@@ -120,6 +126,170 @@ func ReplySegment(messageID string, userID string) Segment {
 	})
 }
 
+// 消息段数据
+
+// TextSegmentData 表示纯文本消息段的数据.
+type TextSegmentData struct {
+	Text     string         `json:"text"` // 纯文本内容
+	Extended ExtendedFields `json:"-"`    // 扩展字段
+}
+
+// AsText 获取纯文本消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsText() (TextSegmentData, error) {
+	var data TextSegmentData
+	err := s.decodeData(SegTypeText, &data)
+	return data, err
+}
+
+// MentionSegmentData 表示提及消息段的数据.
+type MentionSegmentData struct {
+	UserID   string         `json:"user_id"` // 提及用户 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsMention 获取提及消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsMention() (MentionSegmentData, error) {
+	var data MentionSegmentData
+	err := s.decodeData(SegTypeMention, &data)
+	return data, err
+}
+
+// MentionAllSegmentData 表示提及所有人消息段的数据.
+type MentionAllSegmentData struct {
+	Extended ExtendedFields `json:"-"` // 扩展字段
+}
+
+// AsMentionAll 获取提及所有人消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsMentionAll() (MentionAllSegmentData, error) {
+	var data MentionAllSegmentData
+	err := s.decodeData(SegTypeMentionAll, &data)
+	return data, err
+}
+
+// ImageSegmentData 表示图片消息段的数据.
+type ImageSegmentData struct {
+	FileID   string         `json:"file_id"` // 图片文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsImage 获取图片消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsImage() (ImageSegmentData, error) {
+	var data ImageSegmentData
+	err := s.decodeData(SegTypeImage, &data)
+	return data, err
+}
+
+// VoiceSegmentData 表示语音消息段的数据.
+type VoiceSegmentData struct {
+	FileID   string         `json:"file_id"` // 语音文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsVoice 获取语音消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsVoice() (VoiceSegmentData, error) {
+	var data VoiceSegmentData
+	err := s.decodeData(SegTypeVoice, &data)
+	return data, err
+}
+
+// AudioSegmentData 表示音频消息段的数据.
+type AudioSegmentData struct {
+	FileID   string         `json:"file_id"` // 音频文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsAudio 获取音频消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsAudio() (AudioSegmentData, error) {
+	var data AudioSegmentData
+	err := s.decodeData(SegTypeAudio, &data)
+	return data, err
+}
+
+// VideoSegmentData 表示视频消息段的数据.
+type VideoSegmentData struct {
+	FileID   string         `json:"file_id"` // 视频文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsVideo 获取视频消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsVideo() (VideoSegmentData, error) {
+	var data VideoSegmentData
+	err := s.decodeData(SegTypeVideo, &data)
+	return data, err
+}
+
+// FileSegmentData 表示文件消息段的数据.
+type FileSegmentData struct {
+	FileID   string         `json:"file_id"` // 文件 ID
+	Extended ExtendedFields `json:"-"`       // 扩展字段
+}
+
+// AsFile 获取文件消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsFile() (FileSegmentData, error) {
+	var data FileSegmentData
+	err := s.decodeData(SegTypeFile, &data)
+	return data, err
+}
+
+// LocationSegmentData 表示位置消息段的数据.
+type LocationSegmentData struct {
+	Latitude  float64        `json:"latitude"`  // 纬度
+	Longitude float64        `json:"longitude"` // 经度
+	Title     string         `json:"title"`     // 标题
+	Content   string         `json:"content"`   // 地址内容
+	Extended  ExtendedFields `json:"-"`         // 扩展字段
+}
+
+// AsLocation 获取位置消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsLocation() (LocationSegmentData, error) {
+	var data LocationSegmentData
+	err := s.decodeData(SegTypeLocation, &data)
+	return data, err
+}
+
+// ReplySegmentData 表示回复消息段的数据.
+type ReplySegmentData struct {
+	MessageID string         `json:"message_id"`        // 回复的消息 ID
+	UserID    string         `json:"user_id,omitempty"` // 回复的消息发送者 ID, 可为空
+	Extended  ExtendedFields `json:"-"`                 // 扩展字段
+}
+
+// AsReply 获取回复消息段的数据, 消息段类型不符或数据无效时返回 RetCodeBadSegmentData 返回码的 ActionError.
+func (s Segment) AsReply() (ReplySegmentData, error) {
+	var data ReplySegmentData
+	err := s.decodeData(SegTypeReply, &data)
+	return data, err
+}
+
+func (s Segment) decodeData(type_ string, v interface{}) error {
+	if s.Type != type_ {
+		return NewActionError(RetCodeBadSegmentData, fmt.Errorf("消息段类型 `%v` 不是 `%v`", s.Type, type_))
+	}
+	if err := decodeParams(s.Data, v); err != nil {
+		return NewActionError(RetCodeBadSegmentData, fmt.Errorf("消息段 `%v` 数据无效: %v", s.Type, err))
+	}
+	return nil
+}
+
+// IsExtendedSegmentType 判断消息段类型是否为扩展消息段类型 (带有平台前缀, 如 `qq.face`).
+func IsExtendedSegmentType(type_ string) bool {
+	return strings.Contains(type_, ".")
+}
+
+// Validate 检查消息中的每个消息段是否符合 OneBot 标准的定义.
+//
+// 标准消息段按 SegmentRegistry 中的标准消息段类型定义检查, 缺少必需字段或字段类型错误时, 返回 RetCodeBadSegmentData 返回码的 ActionError;
+// 消息段类型既不是标准类型也不是扩展类型时, 返回 RetCodeUnsupportedSegment 返回码的 ActionError.
+// 扩展消息段不做检查.
+func (m Message) Validate() error {
+	for i, s := range m {
+		if err := validateStandardSegment(s); err != nil {
+			return NewActionError(RetCodeFromError(err), fmt.Errorf("第 %v 个消息段无效: %v", i+1, err))
+		}
+	}
+	return nil
+}
+
 // 消息动作
 // https://12.onebot.dev/interface/message/actions/
 
@@ -127,3 +297,77 @@ const (
 	ActionSendMessage   = "send_message"   // 发送消息
 	ActionDeleteMessage = "delete_message" // 删除消息
 )
+
+// 消息动作响应数据
+
+// SendMessageResponse 表示发送消息结果 (send_message 动作响应数据).
+type SendMessageResponse struct {
+	MessageID string         `json:"message_id"` // 消息 ID
+	Time      float64        `json:"time"`       // 消息成功发出的时间 (Unix 时间戳), 单位: 秒
+	Extended  ExtendedFields `json:"-"`          // 扩展字段
+}
+
+// MarshalJSON 将 SendMessageResponse 编码为 JSON, 扩展字段与标准字段平铺.
+func (d SendMessageResponse) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 SendMessageResponse 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d SendMessageResponse) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// SendMessageParams 表示 send_message 动作的请求参数.
+type SendMessageParams struct {
+	DetailType string         `json:"detail_type"`          // 发送的类型, 可以为 private, group, channel 或扩展的类型
+	UserID     string         `json:"user_id,omitempty"`    // 用户 ID, 当 detail_type 为 private 时必须传入
+	GroupID    string         `json:"group_id,omitempty"`   // 群 ID, 当 detail_type 为 group 时必须传入
+	GuildID    string         `json:"guild_id,omitempty"`   // 群组 ID, 当 detail_type 为 channel 时必须传入
+	ChannelID  string         `json:"channel_id,omitempty"` // 频道 ID, 当 detail_type 为 channel 时必须传入
+	Message    Message        `json:"message"`              // 消息内容
+	Extended   ExtendedFields `json:"-"`                    // 扩展参数
+}
+
+func (p SendMessageParams) validate() error {
+	switch p.DetailType {
+	case "private":
+		if p.UserID == "" {
+			return errors.New("`user_id` 字段不存在")
+		}
+	case "group":
+		if p.GroupID == "" {
+			return errors.New("`group_id` 字段不存在")
+		}
+	case "channel":
+		if p.GuildID == "" || p.ChannelID == "" {
+			return errors.New("`guild_id` 或 `channel_id` 字段不存在")
+		}
+	}
+	return nil
+}
+
+// DeleteMessageParams 表示 delete_message 动作的请求参数.
+type DeleteMessageParams struct {
+	MessageID string         `json:"message_id"` // 唯一的消息 ID
+	Extended  ExtendedFields `json:"-"`          // 扩展参数
+}
+
+// 动作实现接口
+
+// SendMessageImpl 表示实现了 send_message 动作 (发送消息) 的对象.
+type SendMessageImpl interface {
+	SendMessage(r *Request, params SendMessageParams) (SendMessageResponse, error)
+}
+
+// DeleteMessageImpl 表示实现了 delete_message 动作 (撤回消息) 的对象.
+type DeleteMessageImpl interface {
+	DeleteMessage(r *Request, params DeleteMessageParams) error
+}
+
+// MessageImpl 表示实现了消息接口所有动作的对象.
+type MessageImpl interface {
+	SendMessageImpl
+	DeleteMessageImpl
+}
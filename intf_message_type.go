@@ -74,6 +74,8 @@ func (m *Message) ExtractText() (text string) {
 
 func messageFromInterface(interf interface{}) (Message, error) {
 	switch v := interf.(type) {
+	case Message:
+		return v, nil
 	case string:
 		return Message{TextSegment(v)}, nil
 	case map[string]interface{}:
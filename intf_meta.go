@@ -43,6 +43,93 @@ const (
 	ActionGetLatestEvents     = "get_latest_events"     // 获取最新事件列表 (仅 HTTP 通信方式支持)
 	ActionGetSupportedActions = "get_supported_actions" // 获取支持的动作列表
 
+	// LibOneBot 自动处理的扩展元动作
+	ActionGetSupportedSegments = "libonebot.get_supported_segments" // 获取支持的消息段类型列表 (OneBot.Segments 中注册的类型)
+
 	ActionGetStatus  = "get_status"  // 获取 OneBot 运行状态
 	ActionGetVersion = "get_version" // 获取 OneBot 版本信息
 )
+
+// 元动作响应数据
+
+// Status 表示OneBot 运行状态 (get_status 动作响应数据).
+type Status struct {
+	Good     bool           `json:"good"` // 是否各项状态都符合预期, OneBot 实现各模块都正常
+	Bots     []BotStatus    `json:"bots"` // 当前 OneBot Connect 连接上所有机器人账号的状态列表
+	Extended ExtendedFields `json:"-"`    // 扩展字段
+}
+
+// MarshalJSON 将 Status 编码为 JSON, 扩展字段与标准字段平铺.
+func (d Status) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 Status 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d Status) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// BotStatus 表示一个机器人账号的状态.
+type BotStatus struct {
+	Self     Self           `json:"self"`   // 机器人自身标识
+	Online   bool           `json:"online"` // 机器人账号是否在线 (可收发消息等)
+	Extended ExtendedFields `json:"-"`      // 扩展字段
+}
+
+// MarshalJSON 将 BotStatus 编码为 JSON, 扩展字段与标准字段平铺.
+func (d BotStatus) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 BotStatus 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d BotStatus) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// VersionInfo 表示OneBot 版本信息 (get_version 动作响应数据).
+type VersionInfo struct {
+	Impl          string         `json:"impl"`           // OneBot 实现名称
+	Version       string         `json:"version"`        // OneBot 实现的版本
+	OneBotVersion string         `json:"onebot_version"` // OneBot 实现的 OneBot 标准版本号
+	Extended      ExtendedFields `json:"-"`              // 扩展字段
+}
+
+// MarshalJSON 将 VersionInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d VersionInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 VersionInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d VersionInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// GetStatusParams 表示 get_status 动作的请求参数.
+type GetStatusParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// GetVersionParams 表示 get_version 动作的请求参数.
+type GetVersionParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// 动作实现接口
+
+// GetStatusImpl 表示实现了 get_status 动作 (获取运行状态) 的对象.
+type GetStatusImpl interface {
+	GetStatus(r *Request, params GetStatusParams) (Status, error)
+}
+
+// GetVersionImpl 表示实现了 get_version 动作 (获取版本信息) 的对象.
+type GetVersionImpl interface {
+	GetVersion(r *Request, params GetVersionParams) (VersionInfo, error)
+}
+
+// MetaImpl 表示实现了元接口所有动作的对象.
+type MetaImpl interface {
+	GetStatusImpl
+	GetVersionImpl
+}
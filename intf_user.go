@@ -76,3 +76,84 @@ const (
 	ActionGetUserInfo   = "get_user_info"   // 获取用户信息
 	ActionGetFriendList = "get_friend_list" // 获取好友列表
 )
+
+// 用户动作响应数据
+
+// SelfInfo 表示机器人自身信息 (get_self_info 动作响应数据).
+type SelfInfo struct {
+	UserID          string         `json:"user_id"`          // 用户 ID
+	UserName        string         `json:"user_name"`        // 用户名称/昵称
+	UserDisplayname string         `json:"user_displayname"` // 用户设置的显示名称, 可为空
+	Extended        ExtendedFields `json:"-"`                // 扩展字段
+}
+
+// MarshalJSON 将 SelfInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d SelfInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 SelfInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d SelfInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// UserInfo 表示用户信息 (get_user_info 动作响应数据, 以及 get_friend_list 动作响应数据的列表项).
+type UserInfo struct {
+	UserID          string         `json:"user_id"`          // 用户 ID
+	UserName        string         `json:"user_name"`        // 用户名称/昵称
+	UserDisplayname string         `json:"user_displayname"` // 用户设置的显示名称, 可为空
+	UserRemark      string         `json:"user_remark"`      // 机器人账号对该用户的备注名称, 可为空
+	Extended        ExtendedFields `json:"-"`                // 扩展字段
+}
+
+// MarshalJSON 将 UserInfo 编码为 JSON, 扩展字段与标准字段平铺.
+func (d UserInfo) MarshalJSON() ([]byte, error) {
+	return marshalResponseDataJSON(d, d.Extended)
+}
+
+// MarshalMsgpack 将 UserInfo 编码为 MsgPack, 扩展字段与标准字段平铺.
+func (d UserInfo) MarshalMsgpack() ([]byte, error) {
+	return marshalResponseDataMsgpack(d, d.Extended)
+}
+
+// 动作请求参数
+
+// GetSelfInfoParams 表示 get_self_info 动作的请求参数.
+type GetSelfInfoParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// GetUserInfoParams 表示 get_user_info 动作的请求参数.
+type GetUserInfoParams struct {
+	UserID   string         `json:"user_id"` // 用户 ID
+	Extended ExtendedFields `json:"-"`       // 扩展参数
+}
+
+// GetFriendListParams 表示 get_friend_list 动作的请求参数.
+type GetFriendListParams struct {
+	Extended ExtendedFields `json:"-"` // 扩展参数
+}
+
+// 动作实现接口
+
+// GetSelfInfoImpl 表示实现了 get_self_info 动作 (获取机器人自身信息) 的对象.
+type GetSelfInfoImpl interface {
+	GetSelfInfo(r *Request, params GetSelfInfoParams) (SelfInfo, error)
+}
+
+// GetUserInfoImpl 表示实现了 get_user_info 动作 (获取用户信息) 的对象.
+type GetUserInfoImpl interface {
+	GetUserInfo(r *Request, params GetUserInfoParams) (UserInfo, error)
+}
+
+// GetFriendListImpl 表示实现了 get_friend_list 动作 (获取好友列表) 的对象.
+type GetFriendListImpl interface {
+	GetFriendList(r *Request, params GetFriendListParams) ([]UserInfo, error)
+}
+
+// UserImpl 表示实现了单用户接口所有动作的对象.
+type UserImpl interface {
+	GetSelfInfoImpl
+	GetUserInfoImpl
+	GetFriendListImpl
+}
@@ -0,0 +1,71 @@
+// 日志
+
+package libonebot
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogFields 表示日志的结构化字段.
+type LogFields map[string]interface{}
+
+// Logger 是 LibOneBot 使用的日志接口.
+//
+// 可以通过 NewLogrusLogger 或 NewSlogLogger 适配已有的日志库, 也可以自行实现该接口.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	// WithFields 返回带有指定结构化字段的 Logger, 原 Logger 不受影响
+	WithFields(fields LogFields) Logger
+}
+
+type logrusLogger struct {
+	logrus.FieldLogger
+}
+
+// NewLogrusLogger 将 logrus 的 Logger 或 Entry 适配为 Logger.
+func NewLogrusLogger(l logrus.FieldLogger) Logger {
+	return logrusLogger{l}
+}
+
+func (l logrusLogger) WithFields(fields LogFields) Logger {
+	return logrusLogger{l.FieldLogger.WithFields(logrus.Fields(fields))}
+}
+
+type discardLogger struct{}
+
+// DiscardLogger 是一个丢弃所有日志的 Logger.
+var DiscardLogger Logger = discardLogger{}
+
+func (discardLogger) Debugf(format string, args ...interface{}) {}
+func (discardLogger) Infof(format string, args ...interface{})  {}
+func (discardLogger) Warnf(format string, args ...interface{})  {}
+func (discardLogger) Errorf(format string, args ...interface{}) {}
+
+func (l discardLogger) WithFields(fields LogFields) Logger {
+	return l
+}
+
+// requestLogger 返回带有动作请求相关字段的 Logger.
+func (ob *OneBot) requestLogger(r *Request) Logger {
+	fields := LogFields{"action": r.Action}
+	if r.Echo != "" {
+		fields["echo"] = r.Echo
+	}
+	if comm, ok := commMethodLabels[r.Comm.Method]; ok {
+		fields["comm"] = comm
+	}
+	if r.Comm.RemoteAddr != "" {
+		fields["remote_addr"] = r.Comm.RemoteAddr
+	}
+	if r.Self != nil {
+		fields["self"] = fmt.Sprintf("%v/%v", r.Self.Platform, r.Self.UserID)
+	} else if ob.Self != nil {
+		fields["self"] = fmt.Sprintf("%v/%v", ob.Self.Platform, ob.Self.UserID)
+	}
+	return ob.Logger.WithFields(fields)
+}
@@ -0,0 +1,57 @@
+//go:build go1.21
+// +build go1.21
+
+package libonebot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger 将 log/slog 的 Logger 适配为 Logger.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+func (l slogLogger) log(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, level) {
+		return // avoid formatting the message
+	}
+	l.l.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Debugf(format string, args ...interface{}) {
+	l.log(slog.LevelDebug, format, args...)
+}
+
+func (l slogLogger) Infof(format string, args ...interface{}) {
+	l.log(slog.LevelInfo, format, args...)
+}
+
+func (l slogLogger) Warnf(format string, args ...interface{}) {
+	l.log(slog.LevelWarn, format, args...)
+}
+
+func (l slogLogger) Errorf(format string, args ...interface{}) {
+	l.log(slog.LevelError, format, args...)
+}
+
+func (l slogLogger) WithFields(fields LogFields) Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // stable attribute order
+	attrs := make([]interface{}, 0, len(fields))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return slogLogger{l.l.With(attrs...)}
+}
@@ -0,0 +1,109 @@
+// 消息的替代表示
+
+package libonebot
+
+import (
+	"strings"
+	"sync"
+)
+
+// AltMessageRenderer 将消息渲染为替代表示 (即 alt_message 字段), 每种消息段类型的渲染方式可单独覆盖.
+//
+// 对每个消息段, 依次尝试 SetSegmentRenderer 设置的渲染函数, 消息段类型注册表中的 SegmentType.AltText,
+// 以及标准消息段的默认渲染方式; 均不存在时渲染为 `[类型名称]`.
+type AltMessageRenderer struct {
+	segments      *SegmentRegistry
+	renderers     map[string]func(Segment) string
+	renderersLock *sync.RWMutex
+}
+
+// NewAltMessageRenderer 创建一个新的 AltMessageRenderer 对象.
+//
+// 参数:
+//   segments: 消息段类型注册表, 用于获取扩展消息段的渲染函数, 可为 nil
+func NewAltMessageRenderer(segments *SegmentRegistry) *AltMessageRenderer {
+	return &AltMessageRenderer{
+		segments:      segments,
+		renderers:     make(map[string]func(Segment) string),
+		renderersLock: &sync.RWMutex{},
+	}
+}
+
+var defaultAltRenderers = map[string]func(Segment) string{
+	SegTypeText: func(s Segment) string {
+		text, _ := s.Data.GetString("text")
+		return text
+	},
+	SegTypeMention: func(s Segment) string {
+		userID, _ := s.Data.GetString("user_id")
+		return "@" + userID
+	},
+	SegTypeMentionAll: func(s Segment) string { return "@全体成员" },
+	SegTypeImage:      func(s Segment) string { return "[图片]" },
+	SegTypeVoice:      func(s Segment) string { return "[语音]" },
+	SegTypeAudio:      func(s Segment) string { return "[音频]" },
+	SegTypeVideo:      func(s Segment) string { return "[视频]" },
+	SegTypeFile:       func(s Segment) string { return "[文件]" },
+	SegTypeLocation: func(s Segment) string {
+		title, _ := s.Data.GetString("title")
+		if title == "" {
+			return "[位置]"
+		}
+		return "[位置: " + title + "]"
+	},
+	SegTypeReply: func(s Segment) string {
+		userID, _ := s.Data.GetString("user_id")
+		if userID == "" {
+			return "[回复]"
+		}
+		return "[回复 @" + userID + "]"
+	},
+}
+
+// SetSegmentRenderer 设置指定消息段类型的渲染函数, 传入 nil 则恢复默认渲染方式.
+func (r *AltMessageRenderer) SetSegmentRenderer(type_ string, render func(Segment) string) {
+	r.renderersLock.Lock()
+	defer r.renderersLock.Unlock()
+	if render == nil {
+		delete(r.renderers, type_)
+	} else {
+		r.renderers[type_] = render
+	}
+}
+
+// RenderSegment 渲染一个消息段.
+func (r *AltMessageRenderer) RenderSegment(s Segment) string {
+	r.renderersLock.RLock()
+	render, ok := r.renderers[s.Type]
+	r.renderersLock.RUnlock()
+	if ok {
+		return render(s)
+	}
+	if r.segments != nil {
+		if t, ok := r.segments.Lookup(s.Type); ok && t.AltText != nil {
+			return t.AltText(s)
+		}
+	}
+	if render, ok := defaultAltRenderers[s.Type]; ok {
+		return render(s)
+	}
+	return "[" + s.Type + "]"
+}
+
+// Render 渲染一条消息.
+func (r *AltMessageRenderer) Render(m Message) string {
+	var sb strings.Builder
+	for _, s := range m {
+		sb.WriteString(r.RenderSegment(s))
+	}
+	return sb.String()
+}
+
+// messageEventHolder 由 MessageEvent 及嵌入了 MessageEvent 的事件类型实现.
+type messageEventHolder interface {
+	messageEvent() *MessageEvent
+}
+
+func (e *MessageEvent) messageEvent() *MessageEvent {
+	return e
+}
@@ -0,0 +1,104 @@
+// 消息构造器
+
+package libonebot
+
+// MessageBuilder 用于以链式调用的方式构造消息.
+//
+// 示例:
+//   message := NewMessage().Reply(messageID, userID).Mention(userID).Text(" 你好").Image(fileID).Build()
+type MessageBuilder struct {
+	m Message
+}
+
+// NewMessage 创建一个新的 MessageBuilder 对象.
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{
+		m: Message{},
+	}
+}
+
+// Segment 追加一个消息段, 消息段数据将被复制.
+func (b *MessageBuilder) Segment(s Segment) *MessageBuilder {
+	b.m = append(b.m, s.Copy())
+	return b
+}
+
+// Message 追加一条消息中的所有消息段, 消息段数据将被复制.
+func (b *MessageBuilder) Message(m Message) *MessageBuilder {
+	b.m = append(b.m, m.Copy()...)
+	return b
+}
+
+// Custom 追加一个指定类型的消息段.
+func (b *MessageBuilder) Custom(type_ string, data map[string]interface{}) *MessageBuilder {
+	return b.Segment(CustomSegment(type_, data))
+}
+
+// Text 追加一个纯文本消息段.
+func (b *MessageBuilder) Text(text string) *MessageBuilder {
+	b.m = append(b.m, TextSegment(text))
+	return b
+}
+
+// Mention 追加一个提及消息段.
+func (b *MessageBuilder) Mention(userID string) *MessageBuilder {
+	b.m = append(b.m, MentionSegment(userID))
+	return b
+}
+
+// MentionAll 追加一个提及所有人消息段.
+func (b *MessageBuilder) MentionAll() *MessageBuilder {
+	b.m = append(b.m, MentionAllSegment())
+	return b
+}
+
+// Image 追加一个图片消息段.
+func (b *MessageBuilder) Image(fileID string) *MessageBuilder {
+	b.m = append(b.m, ImageSegment(fileID))
+	return b
+}
+
+// Voice 追加一个语音消息段.
+func (b *MessageBuilder) Voice(fileID string) *MessageBuilder {
+	b.m = append(b.m, VoiceSegment(fileID))
+	return b
+}
+
+// Audio 追加一个音频消息段.
+func (b *MessageBuilder) Audio(fileID string) *MessageBuilder {
+	b.m = append(b.m, AudioSegment(fileID))
+	return b
+}
+
+// Video 追加一个视频消息段.
+func (b *MessageBuilder) Video(fileID string) *MessageBuilder {
+	b.m = append(b.m, VideoSegment(fileID))
+	return b
+}
+
+// File 追加一个文件消息段.
+func (b *MessageBuilder) File(fileID string) *MessageBuilder {
+	b.m = append(b.m, FileSegment(fileID))
+	return b
+}
+
+// Location 追加一个位置消息段.
+func (b *MessageBuilder) Location(latitude float64, longitude float64, title string, content string) *MessageBuilder {
+	b.m = append(b.m, LocationSegment(latitude, longitude, title, content))
+	return b
+}
+
+// Reply 追加一个回复消息段.
+func (b *MessageBuilder) Reply(messageID string, userID string) *MessageBuilder {
+	b.m = append(b.m, ReplySegment(messageID, userID))
+	return b
+}
+
+// Build 合并连续的可合并消息段, 并返回构造的消息.
+//
+// 返回的消息与 MessageBuilder 互不影响, Build 之后可以继续追加消息段.
+func (b *MessageBuilder) Build() Message {
+	m := b.m.Copy()
+	m.Reduce()
+	return m
+}
@@ -0,0 +1,241 @@
+// OneBot 11 CQ 码
+// https://github.com/botuniverse/onebot-11/blob/master/message/string.md
+
+package libonebot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CQ 码格式及与 OneBot 12 消息段的对应关系.
+const (
+	cqTypeAt       = "at"
+	cqTypeRecord   = "record"
+	cqTypeFace     = "face"
+	cqAtAll        = "all"
+	cqExtraPrefix  = "cq."     // 标准消息段中没有对应字段的 CQ 码参数将以该前缀保存在消息段数据中
+	segTypeQQFace  = "qq.face" // CQ 码 face 类型对应的扩展消息段类型
+	cqCodePrefix   = "[CQ:"
+	cqCodeSuffix   = "]"
+	cqParamDivider = ","
+)
+
+var (
+	cqTextEscaper  = strings.NewReplacer("&", "&amp;", "[", "&#91;", "]", "&#93;")
+	cqParamEscaper = strings.NewReplacer("&", "&amp;", "[", "&#91;", "]", "&#93;", ",", "&#44;")
+	cqUnescaper    = strings.NewReplacer("&#91;", "[", "&#93;", "]", "&#44;", ",", "&amp;", "&")
+)
+
+// ParseCQCode 将 OneBot 11 的 CQ 码字符串解析为 Message.
+//
+// CQ 码类型 at, image, record, video, reply, location 将转换为对应的标准消息段, face 转换为 `qq.face` 扩展消息段,
+// 其它类型原样转换为同名消息段, 参数值均为字符串.
+func ParseCQCode(s string) (Message, error) {
+	m := Message{}
+	for len(s) > 0 {
+		start := strings.Index(s, cqCodePrefix)
+		if start < 0 {
+			m = append(m, TextSegment(cqUnescaper.Replace(s)))
+			break
+		}
+		if start > 0 {
+			m = append(m, TextSegment(cqUnescaper.Replace(s[:start])))
+		}
+		end := strings.Index(s[start:], cqCodeSuffix)
+		if end < 0 {
+			return nil, fmt.Errorf("CQ 码未闭合: %v", s[start:])
+		}
+		seg, err := parseCQCodeSegment(s[start+len(cqCodePrefix) : start+end])
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, seg)
+		s = s[start+end+len(cqCodeSuffix):]
+	}
+	m.Reduce()
+	return m, nil
+}
+
+func parseCQCodeSegment(code string) (Segment, error) {
+	parts := strings.Split(code, cqParamDivider)
+	type_ := parts[0]
+	if type_ == "" {
+		return Segment{}, fmt.Errorf("CQ 码类型为空")
+	}
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return Segment{}, fmt.Errorf("CQ 码 `%v` 参数格式错误: %v", type_, part)
+		}
+		params[kv[0]] = cqUnescaper.Replace(kv[1])
+	}
+	return segmentFromCQParams(type_, params)
+}
+
+// segmentFromCQParams 将 CQ 码 (或 OneBot 11 数组格式消息段) 的类型和参数转换为消息段.
+func segmentFromCQParams(type_ string, params map[string]string) (Segment, error) {
+	require := func(keys ...string) error {
+		for _, k := range keys {
+			if _, ok := params[k]; !ok {
+				return fmt.Errorf("CQ 码 `%v` 缺少 `%v` 参数", type_, k)
+			}
+		}
+		return nil
+	}
+
+	var seg Segment
+	var known []string
+	switch type_ {
+	case SegTypeText:
+		return TextSegment(params["text"]), nil
+	case cqTypeAt:
+		if err := require("qq"); err != nil {
+			return Segment{}, err
+		}
+		if params["qq"] == cqAtAll {
+			seg = MentionAllSegment()
+		} else {
+			seg = MentionSegment(params["qq"])
+		}
+		known = []string{"qq"}
+	case SegTypeImage, cqTypeRecord, SegTypeVideo:
+		if err := require("file"); err != nil {
+			return Segment{}, err
+		}
+		switch type_ {
+		case SegTypeImage:
+			seg = ImageSegment(params["file"])
+		case cqTypeRecord:
+			seg = VoiceSegment(params["file"])
+		default:
+			seg = VideoSegment(params["file"])
+		}
+		known = []string{"file"}
+	case SegTypeReply:
+		if err := require("id"); err != nil {
+			return Segment{}, err
+		}
+		seg, known = ReplySegment(params["id"], params["qq"]), []string{"id", "qq"}
+	case SegTypeLocation:
+		if err := require("lat", "lon"); err != nil {
+			return Segment{}, err
+		}
+		lat, err1 := strconv.ParseFloat(params["lat"], 64)
+		lon, err2 := strconv.ParseFloat(params["lon"], 64)
+		if err1 != nil || err2 != nil {
+			return Segment{}, fmt.Errorf("CQ 码 `%v` 经纬度格式错误", type_)
+		}
+		seg = LocationSegment(lat, lon, params["title"], params["content"])
+		known = []string{"lat", "lon", "title", "content"}
+	case cqTypeFace:
+		if err := require("id"); err != nil {
+			return Segment{}, err
+		}
+		seg, known = CustomSegment(segTypeQQFace, map[string]interface{}{"id": params["id"]}), []string{"id"}
+	default:
+		data := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			data[k] = v
+		}
+		return CustomSegment(type_, data), nil
+	}
+
+	// keep the params that have no equivalent in the standard segment
+	for k, v := range params {
+		if !containsString(known, k) {
+			seg.Data.Set(cqExtraPrefix+k, v)
+		}
+	}
+	return seg, nil
+}
+
+// ToCQCode 将消息转换为 OneBot 11 的 CQ 码字符串, 是 ParseCQCode 的逆操作.
+func (m Message) ToCQCode() string {
+	var sb strings.Builder
+	for _, s := range m {
+		if s.Type == SegTypeText {
+			text, _ := s.Data.GetString("text")
+			sb.WriteString(cqTextEscaper.Replace(text))
+			continue
+		}
+		type_, params := segmentToCQParams(s)
+		sb.WriteString(cqCodePrefix)
+		sb.WriteString(type_)
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(cqParamDivider)
+			sb.WriteString(k)
+			sb.WriteString("=")
+			sb.WriteString(cqParamEscaper.Replace(params[k]))
+		}
+		sb.WriteString(cqCodeSuffix)
+	}
+	return sb.String()
+}
+
+func segmentToCQParams(s Segment) (string, map[string]string) {
+	params := make(map[string]string)
+	str := func(key string) string {
+		v, _ := s.Data.Get(key)
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+
+	type_ := s.Type
+	var known []string
+	switch s.Type {
+	case SegTypeMention:
+		type_, params["qq"], known = cqTypeAt, str("user_id"), []string{"user_id"}
+	case SegTypeMentionAll:
+		type_, params["qq"] = cqTypeAt, cqAtAll
+	case SegTypeImage, SegTypeVoice, SegTypeVideo:
+		if s.Type == SegTypeVoice {
+			type_ = cqTypeRecord
+		}
+		params["file"], known = str("file_id"), []string{"file_id"}
+	case SegTypeReply:
+		params["id"], known = str("message_id"), []string{"message_id", "user_id"}
+		if userID := str("user_id"); userID != "" {
+			params["qq"] = userID
+		}
+	case SegTypeLocation:
+		params["lat"], params["lon"] = str("latitude"), str("longitude")
+		params["title"], params["content"] = str("title"), str("content")
+		known = []string{"latitude", "longitude", "title", "content"}
+	case segTypeQQFace:
+		type_, params["id"], known = cqTypeFace, str("id"), []string{"id"}
+	default:
+		for k := range s.Data.Value() {
+			params[k] = str(k)
+		}
+		return type_, params
+	}
+
+	for k := range s.Data.Value() {
+		if strings.HasPrefix(k, cqExtraPrefix) {
+			params[strings.TrimPrefix(k, cqExtraPrefix)] = str(k)
+		} else if !containsString(known, k) {
+			params[k] = str(k)
+		}
+	}
+	return type_, params
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
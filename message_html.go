@@ -0,0 +1,48 @@
+// 消息的 HTML 表示
+
+package libonebot
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ToHTML 将消息转换为 HTML 片段.
+//
+// 文本消息段中的特殊字符将被转义, 换行符转换为 `<br>`; 其它消息段转换为带有 `ob-<类型>` class 的 span 元素,
+// 内容为 alt_message 中的默认表示, 消息段数据中的 ID 放在 data-* 属性中, 位置消息段转换为 geo: 链接.
+func (m Message) ToHTML() string {
+	var sb strings.Builder
+	for _, s := range m {
+		sb.WriteString(segmentToHTML(s))
+	}
+	return sb.String()
+}
+
+func segmentToHTML(s Segment) string {
+	alt := strings.ReplaceAll(html.EscapeString(renderDefaultAlt(s)), "\n", "<br>")
+	str := func(key string) string {
+		v, _ := s.Data.GetString(key)
+		return html.EscapeString(v)
+	}
+	class := "ob-" + html.EscapeString(strings.ReplaceAll(s.Type, ".", "-"))
+
+	switch s.Type {
+	case SegTypeText:
+		return alt
+	case SegTypeMention:
+		return fmt.Sprintf(`<span class="%v" data-user-id="%v">%v</span>`, class, str("user_id"), alt)
+	case SegTypeReply:
+		return fmt.Sprintf(`<span class="%v" data-message-id="%v" data-user-id="%v">%v</span>`,
+			class, str("message_id"), str("user_id"), alt)
+	case SegTypeLocation:
+		lat, _ := s.Data.GetFloat64("latitude")
+		lon, _ := s.Data.GetFloat64("longitude")
+		return fmt.Sprintf(`<a class="%v" href="geo:%v,%v" title="%v">%v</a>`, class, lat, lon, str("content"), alt)
+	case SegTypeImage, SegTypeVoice, SegTypeAudio, SegTypeVideo, SegTypeFile:
+		return fmt.Sprintf(`<span class="%v" data-file-id="%v">%v</span>`, class, str("file_id"), alt)
+	default:
+		return fmt.Sprintf(`<span class="%v">%v</span>`, class, alt)
+	}
+}
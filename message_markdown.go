@@ -0,0 +1,223 @@
+// 消息的 Markdown 表示
+
+package libonebot
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Markdown 表示中非文本消息段使用的链接协议.
+//
+//   mention:<user_id>                          提及用户
+//   mention-all:                               提及所有人
+//   reply:<message_id>[?user_id=<user_id>]     回复
+//   geo:<latitude>,<longitude>[?title=..&content=..] 位置
+//   image:<file_id>, voice:<file_id>, ...      图片, 语音, 音频, 视频, 文件
+const (
+	mdSchemeMention    = "mention"
+	mdSchemeMentionAll = "mention-all"
+	mdSchemeReply      = "reply"
+	mdSchemeGeo        = "geo"
+)
+
+// mdMediaTypes 为可以通过 `<类型>:<file_id>` 链接表示的媒体消息段类型.
+var mdMediaTypes = []string{SegTypeImage, SegTypeVoice, SegTypeAudio, SegTypeVideo, SegTypeFile}
+
+const mdSpecialChars = "\\`*_[]()<>!#~|"
+
+// escapeMarkdown 转义文本中的 Markdown 特殊字符, 换行符转换为硬换行.
+func escapeMarkdown(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '\n' {
+			sb.WriteString("\\\n")
+			continue
+		}
+		if strings.ContainsRune(mdSpecialChars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// ToMarkdown 将消息转换为 Markdown 文本.
+//
+// 文本消息段中的特殊字符将被转义, 提及, 回复, 位置和媒体消息段转换为带有特定协议的链接 (图片为图片链接),
+// 链接文字与 alt_message 中的默认表示一致; 其它消息段转换为 `[类型名称]` 形式的文本, 无法通过 ParseMarkdown 还原.
+func (m Message) ToMarkdown() string {
+	var sb strings.Builder
+	for _, s := range m {
+		sb.WriteString(segmentToMarkdown(s))
+	}
+	return sb.String()
+}
+
+func segmentToMarkdown(s Segment) string {
+	alt := escapeMarkdown(renderDefaultAlt(s))
+	link := func(target string) string {
+		return "[" + alt + "](" + target + ")"
+	}
+	switch s.Type {
+	case SegTypeText:
+		return alt
+	case SegTypeMention:
+		userID, _ := s.Data.GetString("user_id")
+		return link(mdSchemeMention + ":" + url.PathEscape(userID))
+	case SegTypeMentionAll:
+		return link(mdSchemeMentionAll + ":")
+	case SegTypeReply:
+		messageID, _ := s.Data.GetString("message_id")
+		target := mdSchemeReply + ":" + url.PathEscape(messageID)
+		if userID, _ := s.Data.GetString("user_id"); userID != "" {
+			target += "?" + url.Values{"user_id": {userID}}.Encode()
+		}
+		return link(target)
+	case SegTypeLocation:
+		lat, _ := s.Data.GetFloat64("latitude")
+		lon, _ := s.Data.GetFloat64("longitude")
+		title, _ := s.Data.GetString("title")
+		content, _ := s.Data.GetString("content")
+		query := url.Values{"title": {title}, "content": {content}}.Encode()
+		return link(fmt.Sprintf("%v:%v,%v?%v", mdSchemeGeo,
+			strconv.FormatFloat(lat, 'f', -1, 64), strconv.FormatFloat(lon, 'f', -1, 64), query))
+	case SegTypeImage:
+		fileID, _ := s.Data.GetString("file_id")
+		return "!" + link(s.Type+":"+url.PathEscape(fileID))
+	case SegTypeVoice, SegTypeAudio, SegTypeVideo, SegTypeFile:
+		fileID, _ := s.Data.GetString("file_id")
+		return link(s.Type + ":" + url.PathEscape(fileID))
+	default:
+		return alt
+	}
+}
+
+// renderDefaultAlt 使用默认方式渲染消息段的替代表示.
+func renderDefaultAlt(s Segment) string {
+	if render, ok := defaultAltRenderers[s.Type]; ok {
+		return render(s)
+	}
+	return "[" + s.Type + "]"
+}
+
+// ParseMarkdown 将受限的 Markdown 子集解析为 Message, 是 ToMarkdown 的逆操作.
+//
+// 支持的语法仅包括反斜杠转义, 反斜杠硬换行, 以及 `[文字](链接)` 和 `![文字](链接)` 形式的链接,
+// 其余字符均作为普通文本. 链接协议为 ToMarkdown 使用的协议时转换为对应的消息段 (链接文字将被忽略),
+// 其它链接转换为 `文字 (链接)` 形式的文本; 图片链接必须使用 `image:` 协议.
+func ParseMarkdown(s string) (Message, error) {
+	m := Message{}
+	var text strings.Builder
+	flushText := func() {
+		if text.Len() > 0 {
+			m = append(m, TextSegment(text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '\n' || isASCIIPunct(s[i+1])):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '['):
+			isImage := c == '!'
+			start := i
+			if isImage {
+				start++
+			}
+			label, target, n, ok := scanMarkdownLink(s[start:])
+			if !ok {
+				break // not a link, treat as plain text
+			}
+			seg, err := segmentFromMarkdownLink(label, target, isImage)
+			if err != nil {
+				return nil, err
+			}
+			flushText()
+			m = append(m, seg)
+			i = start + n
+			continue
+		}
+		text.WriteByte(c)
+		i++
+	}
+	flushText()
+	m.Reduce()
+	return m, nil
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// scanMarkdownLink 从 `[` 开始扫描一个 `[文字](链接)` 形式的链接, 返回未转义的文字, 链接和扫描的字节数.
+func scanMarkdownLink(s string) (string, string, int, bool) {
+	var label strings.Builder
+	i := 1
+	for ; i < len(s) && s[i] != ']'; i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		} else if s[i] == '[' || s[i] == '\n' {
+			return "", "", 0, false
+		}
+		label.WriteByte(s[i])
+	}
+	if i+1 >= len(s) || s[i+1] != '(' {
+		return "", "", 0, false
+	}
+	end := strings.IndexAny(s[i+2:], ") \n")
+	if end < 0 || s[i+2+end] != ')' {
+		return "", "", 0, false
+	}
+	return label.String(), s[i+2 : i+2+end], i + 2 + end + 1, true
+}
+
+// segmentFromMarkdownLink 将链接转换为消息段, 不使用消息段链接协议的链接转换为文本消息段.
+func segmentFromMarkdownLink(label string, target string, isImage bool) (Segment, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" {
+		u = &url.URL{}
+	}
+	opaque, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		return Segment{}, fmt.Errorf("链接 `%v` 格式错误", target)
+	}
+	query := u.Query()
+
+	if isImage && u.Scheme != SegTypeImage {
+		return Segment{}, fmt.Errorf("不支持的图片链接 `%v`", target)
+	}
+	switch u.Scheme {
+	case mdSchemeMention:
+		return MentionSegment(opaque), nil
+	case mdSchemeMentionAll:
+		return MentionAllSegment(), nil
+	case mdSchemeReply:
+		return ReplySegment(opaque, query.Get("user_id")), nil
+	case mdSchemeGeo:
+		parts := strings.Split(opaque, ",")
+		if len(parts) != 2 {
+			return Segment{}, fmt.Errorf("位置链接 `%v` 格式错误", target)
+		}
+		lat, err1 := strconv.ParseFloat(parts[0], 64)
+		lon, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			return Segment{}, fmt.Errorf("位置链接 `%v` 经纬度格式错误", target)
+		}
+		return LocationSegment(lat, lon, query.Get("title"), query.Get("content")), nil
+	}
+	if containsString(mdMediaTypes, u.Scheme) {
+		return CustomSegment(u.Scheme, map[string]interface{}{"file_id": opaque}), nil
+	}
+
+	if label == target || label == "" {
+		return TextSegment(target), nil
+	}
+	return TextSegment(label + " (" + target + ")"), nil
+}
@@ -0,0 +1,66 @@
+// 回复消息事件
+
+package libonebot
+
+import (
+	"fmt"
+)
+
+// replyTargetHolder 由私聊, 群和频道消息事件及嵌入了这些事件的扩展事件类型实现.
+type replyTargetHolder interface {
+	messageEventHolder
+	// replyTarget 返回回复时的 send_message 动作参数 (不含消息内容) 和原消息发送者 ID.
+	replyTarget() (SendMessageParams, string)
+}
+
+func (e *PrivateMessageEvent) replyTarget() (SendMessageParams, string) {
+	return SendMessageParams{DetailType: "private", UserID: e.UserID}, e.UserID
+}
+
+func (e *GroupMessageEvent) replyTarget() (SendMessageParams, string) {
+	return SendMessageParams{DetailType: "group", GroupID: e.GroupID}, e.UserID
+}
+
+func (e *ChannelMessageEvent) replyTarget() (SendMessageParams, string) {
+	return SendMessageParams{DetailType: "channel", GuildID: e.GuildID, ChannelID: e.ChannelID}, e.UserID
+}
+
+// ReplyTo 构造回复消息事件所需的 send_message 动作参数.
+//
+// 支持私聊, 群和频道消息事件, 以及嵌入了这些事件的扩展事件类型 (需传入指针), 参数中的 detail_type 及用户, 群, 群组和频道 ID 由事件确定.
+// 事件不是上述类型时返回错误.
+//
+// 参数:
+//   event: 要回复的消息事件
+//   msg: 回复的消息内容, 不会被修改
+//   quote: 是否在回复消息开头添加引用原消息的回复消息段
+func ReplyTo(event AnyEvent, msg Message, quote bool) (SendMessageParams, error) {
+	target, ok := event.(replyTargetHolder)
+	if !ok {
+		return SendMessageParams{}, fmt.Errorf("不支持回复 `%T` 类型的事件", event)
+	}
+	params, senderID := target.replyTarget()
+
+	params.Message = make(Message, 0, len(msg)+1)
+	if quote {
+		params.Message = append(params.Message, ReplySegment(target.messageEvent().MessageID, senderID))
+	}
+	params.Message = append(params.Message, msg.Copy()...)
+	return params, nil
+}
+
+// ToMap 将 send_message 动作参数转换为 map, 扩展参数将与标准参数平铺在同一层级, 可用于 OneBot.CallAction.
+func (p SendMessageParams) ToMap() map[string]interface{} {
+	return responseDataToMap(p, p.Extended)
+}
+
+// Reply 通过 send_message 动作回复消息事件, 动作参数由 ReplyTo 构造.
+//
+// 事件不是支持的消息事件类型时返回 RetCodeBadParam 返回码的失败响应.
+func (ob *OneBot) Reply(event AnyEvent, msg Message, quote bool) Response {
+	params, err := ReplyTo(event, msg, quote)
+	if err != nil {
+		return failedResponse(RetCodeBadParam, err)
+	}
+	return ob.CallAction(ActionSendMessage, params.ToMap())
+}
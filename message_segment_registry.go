@@ -0,0 +1,213 @@
+// 消息段类型注册表
+
+package libonebot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SegFieldXxx 表示消息段数据字段的类型.
+const (
+	SegFieldString = "string" // 字符串
+	SegFieldInt    = "int"    // 整数
+	SegFieldFloat  = "float"  // 浮点数
+	SegFieldBool   = "bool"   // 布尔值
+	SegFieldBytes  = "bytes"  // 字节数组 (JSON 中为 Base64 字符串)
+	SegFieldAny    = "any"    // 任意类型
+)
+
+// SegmentField 描述消息段数据中的一个字段.
+type SegmentField struct {
+	Name     string // 字段名
+	Type     string // 字段类型, 取值为 SegFieldXxx
+	Required bool   // 是否为必需字段
+}
+
+// SegmentType 描述一种消息段类型.
+type SegmentType struct {
+	Name      string               // 消息段类型名称, 扩展消息段类型必须带有平台前缀 (如 `qq.face`)
+	Fields    []SegmentField       // 数据字段定义, 为 nil 时不检查数据字段
+	Validator func(Segment) error  // 自定义检查函数, 可为 nil, 返回的错误若不是 ActionError 则视为 RetCodeBadSegmentData
+	AltText   func(Segment) string // 替代表示渲染函数, 可为 nil
+}
+
+// SegmentRegistry 记录 OneBot 实现支持的消息段类型, 用于检查动作请求中的消息.
+//
+// 新创建的 SegmentRegistry 包含所有 OneBot 标准定义的消息段类型,
+// OneBot 实现可以通过 Register 注册扩展消息段类型, 或通过 Unregister 移除不支持的标准消息段类型.
+type SegmentRegistry struct {
+	types     map[string]SegmentType
+	typesLock *sync.RWMutex
+}
+
+var standardSegmentTypes = []SegmentType{
+	{Name: SegTypeText, Fields: []SegmentField{{"text", SegFieldString, true}}},
+	{Name: SegTypeMention, Fields: []SegmentField{{"user_id", SegFieldString, true}}},
+	{Name: SegTypeMentionAll, Fields: []SegmentField{}},
+	{Name: SegTypeImage, Fields: []SegmentField{{"file_id", SegFieldString, true}}},
+	{Name: SegTypeVoice, Fields: []SegmentField{{"file_id", SegFieldString, true}}},
+	{Name: SegTypeAudio, Fields: []SegmentField{{"file_id", SegFieldString, true}}},
+	{Name: SegTypeVideo, Fields: []SegmentField{{"file_id", SegFieldString, true}}},
+	{Name: SegTypeFile, Fields: []SegmentField{{"file_id", SegFieldString, true}}},
+	{Name: SegTypeLocation, Fields: []SegmentField{
+		{"latitude", SegFieldFloat, true},
+		{"longitude", SegFieldFloat, true},
+		{"title", SegFieldString, true},
+		{"content", SegFieldString, true},
+	}},
+	{Name: SegTypeReply, Fields: []SegmentField{
+		{"message_id", SegFieldString, true},
+		{"user_id", SegFieldString, false},
+	}},
+}
+
+// standardSegmentTypesByName 按名称索引 standardSegmentTypes.
+var standardSegmentTypesByName = func() map[string]SegmentType {
+	types := make(map[string]SegmentType, len(standardSegmentTypes))
+	for _, t := range standardSegmentTypes {
+		types[t.Name] = t
+	}
+	return types
+}()
+
+// NewSegmentRegistry 创建一个新的 SegmentRegistry 对象, 其中包含所有标准消息段类型.
+func NewSegmentRegistry() *SegmentRegistry {
+	r := &SegmentRegistry{
+		types:     make(map[string]SegmentType),
+		typesLock: &sync.RWMutex{},
+	}
+	for _, t := range standardSegmentTypes {
+		r.Register(t)
+	}
+	return r
+}
+
+// Register 注册一种消息段类型, 已存在的同名类型将被覆盖.
+func (r *SegmentRegistry) Register(t SegmentType) {
+	if t.Name == "" {
+		panic("消息段类型名称不能为空")
+	}
+	if _, ok := standardSegmentTypesByName[t.Name]; !ok && !IsExtendedSegmentType(t.Name) {
+		panic("扩展消息段类型名称必须带有平台前缀")
+	}
+	r.typesLock.Lock()
+	r.types[t.Name] = t
+	r.typesLock.Unlock()
+}
+
+// Unregister 移除一种消息段类型.
+func (r *SegmentRegistry) Unregister(name string) {
+	r.typesLock.Lock()
+	delete(r.types, name)
+	r.typesLock.Unlock()
+}
+
+// Lookup 获取指定名称的消息段类型.
+func (r *SegmentRegistry) Lookup(name string) (SegmentType, bool) {
+	r.typesLock.RLock()
+	defer r.typesLock.RUnlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// SupportedTypes 获取所有支持的消息段类型名称, 按字典序排列.
+func (r *SegmentRegistry) SupportedTypes() []string {
+	r.typesLock.RLock()
+	defer r.typesLock.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 根据注册的消息段类型检查消息.
+//
+// 消息段类型未注册时返回 RetCodeUnsupportedSegment, 数据字段缺失或类型错误时返回 RetCodeBadSegmentData,
+// 数据中包含未定义且不带平台前缀的字段时返回 RetCodeUnsupportedSegmentData, 错误均为 ActionError.
+func (r *SegmentRegistry) Validate(m Message) error {
+	for i, s := range m {
+		if err := r.validateSegment(s); err != nil {
+			return NewActionError(RetCodeFromError(err), fmt.Errorf("第 %v 个消息段无效: %v", i+1, err))
+		}
+	}
+	return nil
+}
+
+func (r *SegmentRegistry) validateSegment(s Segment) error {
+	t, ok := r.Lookup(s.Type)
+	if !ok {
+		return NewActionError(RetCodeUnsupportedSegment, fmt.Errorf("不支持的消息段类型 `%v`", s.Type))
+	}
+	return checkSegment(s, t, true)
+}
+
+// validateStandardSegment 按标准消息段类型定义检查消息段, 不检查未定义的字段, 扩展消息段不做检查.
+func validateStandardSegment(s Segment) error {
+	if t, ok := standardSegmentTypesByName[s.Type]; ok {
+		return checkSegment(s, t, false)
+	}
+	if s.Type == "" || !IsExtendedSegmentType(s.Type) {
+		return NewActionError(RetCodeUnsupportedSegment, fmt.Errorf("不支持的消息段类型 `%v`", s.Type))
+	}
+	return nil
+}
+
+// checkSegment 按消息段类型定义检查消息段数据, strict 为 true 时不允许未定义且不带平台前缀的字段.
+func checkSegment(s Segment, t SegmentType, strict bool) error {
+	if t.Fields != nil {
+		known := make(map[string]bool, len(t.Fields))
+		for _, f := range t.Fields {
+			known[f.Name] = true
+			if err := checkSegmentField(s, f); err != nil {
+				return NewActionError(RetCodeBadSegmentData, fmt.Errorf("消息段 `%v` 数据无效: %v", s.Type, err))
+			}
+		}
+		if strict {
+			for k := range s.Data.Value() {
+				if !known[k] && !IsExtendedSegmentType(k) {
+					return NewActionError(RetCodeUnsupportedSegmentData, fmt.Errorf("消息段 `%v` 不支持 `%v` 字段", s.Type, k))
+				}
+			}
+		}
+	}
+	if t.Validator != nil {
+		if err := t.Validator(s); err != nil {
+			var actionErr *ActionError
+			if !errors.As(err, &actionErr) {
+				return NewActionError(RetCodeBadSegmentData, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSegmentField(s Segment, f SegmentField) error {
+	if _, err := s.Data.Get(f.Name); err != nil {
+		if f.Required {
+			return err
+		}
+		return nil
+	}
+	var err error
+	switch f.Type {
+	case SegFieldString:
+		_, err = s.Data.GetString(f.Name)
+	case SegFieldInt:
+		_, err = s.Data.GetInt64(f.Name)
+	case SegFieldFloat:
+		_, err = s.Data.GetFloat64(f.Name)
+	case SegFieldBool:
+		_, err = s.Data.GetBool(f.Name)
+	case SegFieldBytes:
+		if _, err = s.Data.GetBytes(f.Name); err != nil {
+			_, err = s.Data.GetString(f.Name)
+		}
+	}
+	return err
+}
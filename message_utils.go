@@ -0,0 +1,202 @@
+// 消息操作
+
+package libonebot
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Copy 深复制消息段, 返回的消息段与原消息段不共享任何数据.
+func (s Segment) Copy() Segment {
+	return Segment{
+		Type: s.Type,
+		Data: EasierMapFromMap(copyValue(s.Data.Value()).(map[string]interface{})),
+	}
+}
+
+// copyValue 深复制 map, 切片和字节数组, 其它类型的值原样返回.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return make(map[string]interface{})
+		}
+		c := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			c[k] = copyValue(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = copyValue(item)
+		}
+		return c
+	case []byte:
+		return append([]byte{}, v...)
+	case map[string]string:
+		c := make(map[string]string, len(v))
+		for k, item := range v {
+			c[k] = item
+		}
+		return c
+	case []string:
+		return append([]string{}, v...)
+	case Message:
+		return v.Copy()
+	default:
+		return v
+	}
+}
+
+// Copy 深复制消息, 返回的消息与原消息不共享任何数据.
+func (m Message) Copy() Message {
+	if m == nil {
+		return nil
+	}
+	c := make(Message, len(m))
+	for i, s := range m {
+		c[i] = s.Copy()
+	}
+	return c
+}
+
+// Equal 判断两个消息段的类型和数据是否相同.
+//
+// 数据按值比较, 数值类型也必须相同 (如 int64(1) 与 float64(1) 不相等).
+func (s Segment) Equal(other Segment) bool {
+	if s.Type != other.Type {
+		return false
+	}
+	d1, d2 := s.Data.Value(), other.Data.Value()
+	if len(d1) == 0 && len(d2) == 0 {
+		return true // treat nil and empty data as equal
+	}
+	return reflect.DeepEqual(d1, d2)
+}
+
+// Equal 判断两条消息的各消息段是否依次相同.
+func (m Message) Equal(other Message) bool {
+	if len(m) != len(other) {
+		return false
+	}
+	for i := range m {
+		if !m[i].Equal(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReplaceType 将消息中指定类型的消息段替换为 replace 返回的消息段 (可以为空以删除消息段), 返回新的消息.
+//
+// 原消息不会被修改, 返回的消息中连续的可合并消息段将被合并.
+func (m Message) ReplaceType(type_ string, replace func(Segment) Message) Message {
+	result := make(Message, 0, len(m))
+	for _, s := range m {
+		if s.Type == type_ {
+			result = append(result, replace(s.Copy()).Copy()...)
+		} else {
+			result = append(result, s.Copy())
+		}
+	}
+	result.Reduce()
+	return result
+}
+
+// StripLeadingMention 查找消息开头 (跳过回复消息段和空白文本) 对指定用户的提及, 若存在则返回删除该提及
+// 及其后空白字符的新消息, 以及 true; 否则返回原消息的副本, 以及 false.
+//
+// 通常用于判断群聊消息是否在提及机器人, 并去除提及以获取命令文本.
+func (m Message) StripLeadingMention(userID string) (Message, bool) {
+	result := m.Copy()
+	for i, s := range result {
+		if s.Type == SegTypeReply || isBlankText(s) {
+			continue
+		}
+		if mentioned, _ := s.Data.GetString("user_id"); s.Type != SegTypeMention || mentioned != userID {
+			return result, false
+		}
+		result = append(result[:i], result[i+1:]...)
+		if i < len(result) && result[i].Type == SegTypeText {
+			text, _ := result[i].Data.GetString("text")
+			if text = strings.TrimLeftFunc(text, unicode.IsSpace); text == "" {
+				result = append(result[:i], result[i+1:]...)
+			} else {
+				result[i].Data.Set("text", text)
+			}
+		}
+		return result, true
+	}
+	return result, false
+}
+
+func isBlankText(s Segment) bool {
+	text, _ := s.Data.GetString("text")
+	return s.Type == SegTypeText && strings.TrimSpace(text) == ""
+}
+
+// Split 将消息拆分为多条长度不超过 maxLen 的消息, 用于发送超出平台长度限制的消息.
+//
+// 消息长度为各消息段的替代表示 (见 AltMessageRenderer) 的字符数之和. 只有纯文本消息段会被拆开,
+// 优先在换行符处拆分; 其它消息段不会被拆开, 长度超过 maxLen 的非文本消息段将单独成为一条消息.
+// maxLen 不大于 0 时返回只包含原消息副本的切片.
+func (m Message) Split(maxLen int) []Message {
+	if maxLen <= 0 {
+		return []Message{m.Copy()}
+	}
+
+	result := make([]Message, 0)
+	current, currentLen := Message{}, 0
+	flush := func() {
+		if len(current) > 0 {
+			current.Reduce()
+			result = append(result, current)
+			current, currentLen = Message{}, 0
+		}
+	}
+
+	for _, s := range m {
+		if s.Type != SegTypeText {
+			segLen := utf8.RuneCountInString(renderDefaultAlt(s))
+			if currentLen+segLen > maxLen {
+				flush()
+			}
+			current = append(current, s.Copy())
+			currentLen += segLen
+			continue
+		}
+
+		text, _ := s.Data.GetString("text")
+		for text != "" {
+			if currentLen >= maxLen {
+				flush()
+			}
+			head, rest := splitText(text, maxLen-currentLen)
+			current = append(current, TextSegment(head))
+			currentLen += utf8.RuneCountInString(head)
+			text = rest
+		}
+	}
+	flush()
+	return result
+}
+
+// splitText 从 s 的开头截取最多 n 个字符, 前 n 个字符中有换行符时在最后一个换行符之后截断.
+func splitText(s string, n int) (string, string) {
+	if utf8.RuneCountInString(s) <= n {
+		return s, ""
+	}
+	cut := 0
+	for i := 0; i < n; i++ {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+	}
+	if newline := strings.LastIndexByte(s[:cut], '\n'); newline >= 0 {
+		return s[:newline+1], s[newline+1:]
+	}
+	return s[:cut], s[cut:]
+}
@@ -0,0 +1,331 @@
+// 运行指标
+
+package libonebot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	metricTypeCounter   = "counter"
+	metricTypeGauge     = "gauge"
+	metricTypeHistogram = "histogram"
+)
+
+// actionDurationBuckets 为动作处理耗时直方图的分桶上界, 单位: 秒.
+var actionDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics 记录 OneBot 实例的运行指标, 并以 Prometheus 文本格式输出.
+//
+// 包含以下指标:
+//   onebot_action_requests_total{action,retcode}: 处理的动作请求数
+//   onebot_action_duration_seconds{action,retcode}: 动作请求处理耗时
+//   onebot_events_pushed_total{event}: 推送的事件数
+//   onebot_events_dropped_total{event,reason}: 被丢弃的事件数, reason 为 invalid, intercepted, duplicate 或 marshal_failed
+//   onebot_ws_connections{comm,addr}: 当前 WebSocket 连接数
+//   onebot_ws_reconnects_total{comm,addr}: 反向 WebSocket 重连次数
+//   onebot_webhook_deliveries_total{url,result}: HTTP Webhook 事件推送次数
+//   onebot_latest_events_buffer_depth{addr}: HTTP 通信方式 get_latest_events 缓冲区中的事件数
+//
+// 为避免客户端发送的任意动作名称产生无限多的时间序列, 不支持的动作 (返回码为 RetCodeUnsupportedAction) 的 action 标签记为 unknown;
+// url 和 addr 标签中的 URL 不包含用户信息和查询参数, 以免访问令牌等敏感信息出现在运行指标中.
+type Metrics struct {
+	families     []*metricFamily
+	familiesLock *sync.RWMutex
+
+	actionRequests    *metricFamily
+	actionDuration    *metricFamily
+	eventsPushed      *metricFamily
+	eventsDropped     *metricFamily
+	wsConnections     *metricFamily
+	wsReconnects      *metricFamily
+	webhookDeliveries *metricFamily
+	latestEventsDepth *metricFamily
+}
+
+type metricFamily struct {
+	name       string
+	help       string
+	type_      string
+	labelNames []string
+	buckets    []float64 // histogram only
+	series     map[string]*metricSeries
+	lock       *sync.Mutex
+}
+
+type metricSeries struct {
+	labelValues  []string
+	value        float64  // counter or gauge value, or histogram sum
+	count        uint64   // histogram only
+	bucketCounts []uint64 // histogram only, not cumulative
+}
+
+// NewMetrics 创建一个新的 Metrics 对象.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		families:     make([]*metricFamily, 0),
+		familiesLock: &sync.RWMutex{},
+	}
+	m.actionRequests = m.newFamily("onebot_action_requests_total", "处理的动作请求数", metricTypeCounter, nil, "action", "retcode")
+	m.actionDuration = m.newFamily("onebot_action_duration_seconds", "动作请求处理耗时", metricTypeHistogram, actionDurationBuckets, "action", "retcode")
+	m.eventsPushed = m.newFamily("onebot_events_pushed_total", "推送的事件数", metricTypeCounter, nil, "event")
+	m.eventsDropped = m.newFamily("onebot_events_dropped_total", "被丢弃的事件数", metricTypeCounter, nil, "event", "reason")
+	m.wsConnections = m.newFamily("onebot_ws_connections", "当前 WebSocket 连接数", metricTypeGauge, nil, "comm", "addr")
+	m.wsReconnects = m.newFamily("onebot_ws_reconnects_total", "反向 WebSocket 重连次数", metricTypeCounter, nil, "comm", "addr")
+	m.webhookDeliveries = m.newFamily("onebot_webhook_deliveries_total", "HTTP Webhook 事件推送次数", metricTypeCounter, nil, "url", "result")
+	m.latestEventsDepth = m.newFamily("onebot_latest_events_buffer_depth", "get_latest_events 缓冲区中的事件数", metricTypeGauge, nil, "addr")
+	return m
+}
+
+func (m *Metrics) newFamily(name string, help string, type_ string, buckets []float64, labelNames ...string) *metricFamily {
+	f := &metricFamily{
+		name:       name,
+		help:       help,
+		type_:      type_,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*metricSeries),
+		lock:       &sync.Mutex{},
+	}
+	m.familiesLock.Lock()
+	m.families = append(m.families, f)
+	m.familiesLock.Unlock()
+	return f
+}
+
+func (f *metricFamily) getSeries(labelValues []string) *metricSeries {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("指标 `%v` 的标签数量错误", f.name))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: append([]string{}, labelValues...)}
+		if f.type_ == metricTypeHistogram {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *metricFamily) add(delta float64, labelValues ...string) {
+	f.lock.Lock()
+	f.getSeries(labelValues).value += delta
+	f.lock.Unlock()
+}
+
+func (f *metricFamily) set(value float64, labelValues ...string) {
+	f.lock.Lock()
+	f.getSeries(labelValues).value = value
+	f.lock.Unlock()
+}
+
+func (f *metricFamily) observe(value float64, labelValues ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	s := f.getSeries(labelValues)
+	s.value += value
+	s.count++
+	for i, upper := range f.buckets {
+		if value <= upper {
+			s.bucketCounts[i]++
+			break
+		}
+	}
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	m.familiesLock.RLock()
+	families := m.families
+	m.familiesLock.RUnlock()
+	for _, f := range families {
+		f.writeTo(&buf)
+	}
+	return buf.WriteTo(w)
+}
+
+func (f *metricFamily) writeTo(buf *bytes.Buffer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fmt.Fprintf(buf, "# HELP %v %v\n", f.name, escapeMetricHelp(f.help))
+	fmt.Fprintf(buf, "# TYPE %v %v\n", f.name, f.type_)
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		if f.type_ != metricTypeHistogram {
+			fmt.Fprintf(buf, "%v%v %v\n", f.name, formatMetricLabels(f.labelNames, s.labelValues), formatMetricValue(s.value))
+			continue
+		}
+		cumulative := uint64(0)
+		for i, upper := range f.buckets {
+			cumulative += s.bucketCounts[i]
+			fmt.Fprintf(buf, "%v_bucket%v %v\n", f.name, f.bucketLabels(s, formatMetricValue(upper)), cumulative)
+		}
+		fmt.Fprintf(buf, "%v_bucket%v %v\n", f.name, f.bucketLabels(s, "+Inf"), s.count)
+		fmt.Fprintf(buf, "%v_sum%v %v\n", f.name, formatMetricLabels(f.labelNames, s.labelValues), formatMetricValue(s.value))
+		fmt.Fprintf(buf, "%v_count%v %v\n", f.name, formatMetricLabels(f.labelNames, s.labelValues), s.count)
+	}
+}
+
+func (f *metricFamily) bucketLabels(s *metricSeries, le string) string {
+	names := append(append([]string{}, f.labelNames...), "le")
+	values := append(append([]string{}, s.labelValues...), le)
+	return formatMetricLabels(names, values)
+}
+
+var (
+	metricHelpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	metricLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeMetricHelp(s string) string {
+	return metricHelpEscaper.Replace(s)
+}
+
+func formatMetricLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + metricLabelEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler 返回以 Prometheus 文本格式输出指标的 HTTP 处理器, 可挂载到 OneBot 实现自己的 HTTP 服务器上.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// commMethodLabels 为各通信方式在指标标签中的名称.
+var commMethodLabels = map[int]string{
+	CommMethodHTTP:         "http",
+	CommMethodHTTPWebhook:  "http_webhook",
+	CommMethodWS:           "ws",
+	CommMethodWSReverse:    "ws_reverse",
+	CommMethodV11HTTP:      "v11_http",
+	CommMethodV11WS:        "v11_ws",
+	CommMethodV11WSReverse: "v11_ws_reverse",
+}
+
+// unknownActionLabel 为不支持的动作在 action 标签中的名称.
+const unknownActionLabel = "unknown"
+
+func (m *Metrics) observeAction(action string, retCode int, seconds float64) {
+	if retCode == RetCodeUnsupportedAction {
+		action = unknownActionLabel
+	}
+	retCodeLabel := strconv.Itoa(retCode)
+	m.actionRequests.add(1, action, retCodeLabel)
+	m.actionDuration.observe(seconds, action, retCodeLabel)
+}
+
+func (m *Metrics) eventPushed(name string) {
+	m.eventsPushed.add(1, name)
+}
+
+func (m *Metrics) eventDropped(name string, reason string) {
+	m.eventsDropped.add(1, name, reason)
+}
+
+func (m *Metrics) wsConnectionChanged(method int, addr string, delta float64) {
+	m.wsConnections.add(delta, commMethodLabels[method], urlLabel(addr))
+}
+
+func (m *Metrics) wsReconnected(method int, addr string) {
+	m.wsReconnects.add(1, commMethodLabels[method], urlLabel(addr))
+}
+
+// urlLabel 去掉 URL 中的用户信息, 查询参数和片段, 用作指标标签和审计记录的请求方身份;
+// 不是 URL 的地址 (如 host:port) 原样返回.
+func urlLabel(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return rawURL
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+func (m *Metrics) webhookDelivered(url string, ok bool) {
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	m.webhookDeliveries.add(1, urlLabel(url), result)
+}
+
+func (m *Metrics) latestEventsDepthChanged(addr string, depth int) {
+	m.latestEventsDepth.set(float64(depth), addr)
+}
+
+func commStartMetrics(c ConfigMetrics, ob *OneBot, ctx context.Context, wg *sync.WaitGroup) error {
+	addr := fmt.Sprintf("%s:%d", c.Host, c.Port)
+	path := c.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	ob.Logger.Infof("正在启动运行指标 HTTP 服务器 (%v%v)...", addr, path)
+
+	mux := http.NewServeMux()
+	mux.Handle(path, ob.Metrics.Handler())
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	component := "metrics " + addr
+	if err := ob.listenAndServe(server, component, fmt.Sprintf("运行指标 HTTP 服务器 (%v)", addr), wg); err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ob.health.remove(component)
+
+		<-ctx.Done()
+		if err := ob.shutdownServer(ctx, server); err != nil {
+			ob.Logger.Errorf("运行指标 HTTP 服务器 (%v) 关闭失败, 错误: %v", addr, err)
+		}
+		ob.Logger.Infof("运行指标 HTTP 服务器 (%v) 已关闭", addr)
+	}()
+	return nil
+}
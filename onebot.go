@@ -2,8 +2,10 @@ package libonebot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,47 +20,69 @@ const (
 // OneBot 表示一个 OneBot 实例.
 type OneBot struct {
 	Impl   string
-	Self   *Self // 机器人自身标识, 多机器人账号复用 OneBot 对象时为 nil
-	Config *Config
-	Logger *logrus.Logger
+	Self   *Self   // 机器人自身标识, 多机器人账号复用 OneBot 对象时为 nil
+	Config *Config // OneBot 配置, 启动后应通过 GetConfig 读取, 通过 ApplyConfig 替换
+	Logger Logger  // 日志, 默认使用 logrus, 可通过 NewLogrusLogger 或 NewSlogLogger 替换
+
+	// 动作参数脱敏规则, 记录动作请求日志和审计记录时使用, 为 nil 时不脱敏
+	ParamRedactor *ParamRedactor
+	// 审计日志, 每次处理动作请求后写入一条审计记录, 为 nil 时不记录
+	AuditSink AuditSink
+	// 机器人平台就绪检查, 返回错误时就绪检查失败, 为 nil 时不检查
+	ReadinessCheck func(ctx context.Context) error
+
+	// 支持的消息段类型, 默认包含所有标准消息段类型, 动作请求中的消息将根据其检查
+	Segments *SegmentRegistry
+	// 消息替代表示渲染器, 推送 alt_message 为空的消息事件时用于自动生成替代表示
+	AltMessageRenderer *AltMessageRenderer
+	// 运行指标, 可通过 Metrics.Handler 或配置中的 metrics 以 Prometheus 文本格式输出
+	Metrics *Metrics
+	// 链路追踪, 默认不记录 Span, 可替换为使用指定 SpanExporter 的 Tracer
+	Tracer *Tracer
 
 	eventListenChans     []chan marshaledEvent
 	eventListenChansLock *sync.RWMutex
+	eventInterceptors    *eventInterceptorChain
 
 	actionHandler Handler
 
-	cancel context.CancelFunc
-	wg     *sync.WaitGroup
+	v11    *v11Adapter
+	health *healthState
+
+	comms      map[string]*commHandle // 运行中的通信方式等组件, 键为组件名称, 停止后为 nil
+	commsLock  *sync.Mutex
+	configLock *sync.Mutex   // 保证启动和 ApplyConfig 不同时进行
+	configRW   *sync.RWMutex // 保护 Config, 使 ApplyConfig 替换配置时可以并发读取
+	runCtx     context.Context
+	cancel     context.CancelFunc
+	drainCtx   context.Context // Shutdown 指定的等待正在处理的请求的截止 context
+	done       chan struct{}   // 完全停止后关闭
+	runLock    *sync.Mutex     // 保护 cancel, drainCtx 和 done
+	runErr     error
+	runErrLock *sync.Mutex
 }
 
+// ErrAlreadyRunning 表示 OneBot 实例已在运行, 不能再次启动.
+var ErrAlreadyRunning = errors.New("OneBot 已在运行")
+
 var (
 	implPlatformRegex = regexp.MustCompile(`^[a-z][\-a-z0-9]*(\.[\-a-z0-9]+)*$`)
 )
 
 // NewOneBot 创建一个新的 OneBot 实例.
 //
+// 参数不合法时 panic, 不检查配置内容, 如需检查配置请使用 TryNewOneBot.
+//
 // 参数:
 //   impl: OneBot 实现名称, 不能为空
 //   self: OneBot 实例对应的机器人自身标识, 不能为 nil
 //   config: OneBot 配置, 不能为 nil
 func NewOneBot(impl string, self *Self, config *Config) *OneBot {
-	if impl == "" {
-		panic("必须提供 OneBot 实现名称")
-	}
-	if !implPlatformRegex.MatchString(impl) {
-		panic("OneBot 实现名称不合法")
-	}
-	if self == nil {
-		panic("必须提供机器人自身标识")
+	if err := checkImpl(impl); err != nil {
+		panic(err.Error())
 	}
-	if self.Platform == "" {
-		panic("必须提供机器人平台名称")
-	}
-	if !implPlatformRegex.MatchString(self.Platform) {
-		panic("机器人平台名称不合法")
-	}
-	if self.UserID == "" {
-		panic("必须提供 OneBot 实例对应的机器人用户 ID")
+	if err := checkSelf(self); err != nil {
+		panic(err.Error())
 	}
 	if config == nil {
 		panic("必须提供 OneBot 配置")
@@ -68,110 +92,450 @@ func NewOneBot(impl string, self *Self, config *Config) *OneBot {
 
 // NewOneBotMultiSelf 创建一个新的多机器人账号复用的 OneBot 实例.
 //
+// 参数不合法时 panic, 不检查配置内容, 如需检查配置请使用 TryNewOneBotMultiSelf.
+//
 // 参数:
 //   impl: OneBot 实现名称, 不能为空
 //   config: OneBot 配置, 不能为 nil
 func NewOneBotMultiSelf(impl string, config *Config) *OneBot {
+	if err := checkImpl(impl); err != nil {
+		panic(err.Error())
+	}
+	if config == nil {
+		panic("必须提供 OneBot 配置")
+	}
+	return newOneBotUnchecked(impl, nil, config)
+}
+
+// TryNewOneBot 创建一个新的 OneBot 实例, 参数不合法或配置无效 (见 Config.Validate) 时返回错误.
+//
+// 参数:
+//   impl: OneBot 实现名称, 不能为空
+//   self: OneBot 实例对应的机器人自身标识, 不能为 nil
+//   config: OneBot 配置, 不能为 nil
+func TryNewOneBot(impl string, self *Self, config *Config) (*OneBot, error) {
+	if err := checkImpl(impl); err != nil {
+		return nil, err
+	}
+	if err := checkSelf(self); err != nil {
+		return nil, err
+	}
+	if err := checkConfig(config); err != nil {
+		return nil, err
+	}
+	return newOneBotUnchecked(impl, self, config), nil
+}
+
+// TryNewOneBotMultiSelf 创建一个新的多机器人账号复用的 OneBot 实例, 参数不合法或配置无效 (见 Config.Validate) 时返回错误.
+//
+// 参数:
+//   impl: OneBot 实现名称, 不能为空
+//   config: OneBot 配置, 不能为 nil
+func TryNewOneBotMultiSelf(impl string, config *Config) (*OneBot, error) {
+	if err := checkImpl(impl); err != nil {
+		return nil, err
+	}
+	if err := checkConfig(config); err != nil {
+		return nil, err
+	}
+	return newOneBotUnchecked(impl, nil, config), nil
+}
+
+func checkImpl(impl string) error {
 	if impl == "" {
-		panic("必须提供 OneBot 实现名称")
+		return errors.New("必须提供 OneBot 实现名称")
 	}
 	if !implPlatformRegex.MatchString(impl) {
-		panic("OneBot 实现名称不合法")
+		return errors.New("OneBot 实现名称不合法")
 	}
+	return nil
+}
+
+func checkSelf(self *Self) error {
+	if self == nil {
+		return errors.New("必须提供机器人自身标识")
+	}
+	if self.Platform == "" {
+		return errors.New("必须提供机器人平台名称")
+	}
+	if !implPlatformRegex.MatchString(self.Platform) {
+		return errors.New("机器人平台名称不合法")
+	}
+	if self.UserID == "" {
+		return errors.New("必须提供 OneBot 实例对应的机器人用户 ID")
+	}
+	return nil
+}
+
+func checkConfig(config *Config) error {
 	if config == nil {
-		panic("必须提供 OneBot 配置")
+		return errors.New("必须提供 OneBot 配置")
 	}
-	return newOneBotUnchecked(impl, nil, config)
+	return config.Validate()
 }
 
 func newOneBotUnchecked(impl string, self *Self, config *Config) *OneBot {
-	return &OneBot{
+	segments := NewSegmentRegistry()
+	ob := &OneBot{
 		Impl:   impl,
 		Self:   self,
 		Config: config,
-		Logger: logrus.New(),
+		Logger: NewLogrusLogger(logrus.New()),
+
+		ParamRedactor: NewParamRedactor(DefaultRedactedParams...),
+
+		Segments:           segments,
+		AltMessageRenderer: NewAltMessageRenderer(segments),
+		Metrics:            NewMetrics(),
+		Tracer:             NewTracer(nil),
 
 		eventListenChans:     make([]chan marshaledEvent, 0),
 		eventListenChansLock: &sync.RWMutex{},
+		eventInterceptors:    newEventInterceptorChain(),
 
 		actionHandler: nil,
 
-		cancel: nil,
-		wg:     &sync.WaitGroup{},
+		commsLock:  &sync.Mutex{},
+		configLock: &sync.Mutex{},
+		configRW:   &sync.RWMutex{},
+		runLock:    &sync.Mutex{},
+		runErrLock: &sync.Mutex{},
 	}
+	ob.v11 = newV11Adapter(ob)
+	ob.health = newHealthState()
+	return ob
+}
+
+// Start 启动 OneBot 实例, 同步监听所有通信方式的地址, 任一通信方式启动失败时停止已启动的部分并返回错误.
+//
+// 启动成功后, ctx 被取消或 Shutdown 被调用时, OneBot 实例停止运行, 可通过 Wait 等待其完全停止.
+// 配置无效时返回 *ConfigError, 不启动任何通信方式; 通信方式启动失败时返回 *StartError, 包含所有启动失败的通信方式的错误.
+// OneBot 实例已在运行 (包括正在停止) 时返回 ErrAlreadyRunning, 停止后可以再次启动.
+func (ob *OneBot) Start(ctx context.Context) error {
+	return ob.start(ctx, true)
+}
+
+// Wait 等待 OneBot 实例完全停止, 返回运行期间发生的第一个错误 (如 HTTP 服务器异常退出).
+func (ob *OneBot) Wait() error {
+	ob.runLock.Lock()
+	done := ob.done
+	ob.runLock.Unlock()
+	if done == nil {
+		return errors.New("OneBot 未启动")
+	}
+	<-done
+	ob.runErrLock.Lock()
+	defer ob.runErrLock.Unlock()
+	return ob.runErr
 }
 
 // Run 运行 OneBot 实例.
 //
 // 该方法会阻塞当前线程, 直到 Shutdown 被调用.
+// 与 Start 不同, 启动失败的通信方式只记录日志, 其它通信方式仍正常运行.
+// OneBot 实例已在运行时记录错误日志并立即返回.
 func (ob *OneBot) Run() {
-	ctx, cancel := context.WithCancel(context.Background())
-	ob.cancel = cancel
+	if err := ob.start(context.Background(), false); err == ErrAlreadyRunning {
+		ob.Logger.Errorf("%v", err)
+		return
+	}
+	ob.Wait()
+}
 
-	ob.startCommMethods(ctx)
-	ob.startHeartbeat(ctx)
+// Shutdown 优雅地停止 OneBot 实例.
+//
+// 所有通信方式停止接受新的动作请求, 等待正在处理的动作请求和 HTTP Webhook 推送完成后,
+// 向 WebSocket 连接发送带有关闭原因的关闭帧, 然后关闭所有连接.
+// ctx 被取消 (如超过截止时间) 时立即返回 ctx.Err(), 所有连接和 HTTP Webhook 推送在后台被强制关闭,
+// 仍在运行的动作处理器不会被中断, 但其响应将被丢弃, 可通过 Wait 等待 OneBot 实例完全停止.
+func (ob *OneBot) Shutdown(ctx context.Context) error {
+	ob.runLock.Lock()
+	cancel, done := ob.cancel, ob.done
+	if cancel == nil {
+		ob.runLock.Unlock()
+		return nil
+	}
+	ob.drainCtx = ctx
+	ob.runLock.Unlock()
 
-	ob.Logger.Infof("OneBot 已启动")
-	<-ctx.Done()
+	cancel() // this will stop everything (comm methods, heartbeat, etc)
+	select {
+	case <-done: // wait for everything to completely stop
+		return nil
+	case <-ctx.Done():
+		ob.Logger.Warnf("OneBot 关闭超时, 强制关闭, 错误: %v", ctx.Err())
+		return ctx.Err()
+	}
 }
 
-// Shutdown 停止 OneBot 实例.
-func (ob *OneBot) Shutdown() {
-	ob.cancel()  // this will stop everything (comm methods, heartbeat, etc)
-	ob.wg.Wait() // wait for everything to completely stop
-	ob.Logger.Infof("OneBot 已关闭")
+// StartError 表示 OneBot 实例启动失败的原因.
+type StartError struct {
+	Errors []error // 各通信方式等组件的启动错误
 }
 
-// GetUserAgent 获取 OneBot 实例的 User-Agent.
-func (ob *OneBot) GetUserAgent() string {
-	return fmt.Sprintf("OneBot/%v LibOneBot/%v", OneBotVersion, Version)
+func (e *StartError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "OneBot 启动失败: " + strings.Join(msgs, "; ")
+}
+
+// commHandle 表示一个运行中的通信方式或其它后台组件, 可以单独停止.
+type commHandle struct {
+	name     string           // 组件名称, 与就绪状态中的相同
+	config   interface{}      // 启动时使用的配置, 用于 ApplyConfig 判断配置是否改变
+	starter  namedCommStarter // 启动组件的函数, 用于 ApplyConfig 在新配置启动失败时恢复原组件
+	drainCtx context.Context  // 单独停止时等待正在处理的请求所使用的 context, 在 cancel 前设置
+	cancel   context.CancelFunc
+	wg       *sync.WaitGroup
 }
 
-func (ob *OneBot) startCommMethods(ctx context.Context) {
-	if ob.Config.Comm.HTTP != nil {
-		for _, c := range ob.Config.Comm.HTTP {
-			ob.wg.Add(1)
-			go commRunHTTP(c, ob, ctx, ob.wg)
+// commHandleKey 是组件 context 中保存其 *commHandle 的键.
+type commHandleKey struct{}
+
+func (h *commHandle) stop() {
+	h.cancel()
+	h.wg.Wait()
+}
+
+// commStarter 同步完成通信方式的检查和监听, 并在 wg 中运行后台 goroutine, 直到 ctx 被取消.
+type commStarter func(ctx context.Context, wg *sync.WaitGroup) error
+
+func (ob *OneBot) start(ctx context.Context, strict bool) error {
+	ob.configLock.Lock()
+	defer ob.configLock.Unlock()
+
+	if ob.running() {
+		return ErrAlreadyRunning
+	}
+	config := ob.GetConfig()
+	if err := config.Validate(); err != nil {
+		if strict {
+			return err
 		}
+		ob.Logger.Warnf("%v", err)
 	}
 
-	if ob.Config.Comm.HTTPWebhook != nil {
-		for _, c := range ob.Config.Comm.HTTPWebhook {
-			ob.wg.Add(1)
-			go commRunHTTPWebhook(c, ob, ctx, ob.wg)
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	ob.runCtx = ctx
+	ob.runLock.Lock()
+	ob.cancel = cancel
+	ob.done = done
+	ob.drainCtx = nil
+	ob.runLock.Unlock()
+	ob.runErrLock.Lock()
+	ob.runErr = nil
+	ob.runErrLock.Unlock()
+	ob.commsLock.Lock()
+	ob.comms = make(map[string]*commHandle)
+	ob.commsLock.Unlock()
+
+	errs := make([]error, 0)
+	for _, s := range ob.commStarters(config) {
+		if _, err := ob.startComm(ctx, s); err != nil {
+			errs = append(errs, err)
 		}
 	}
-
-	if ob.Config.Comm.WS != nil {
-		for _, c := range ob.Config.Comm.WS {
-			ob.wg.Add(1)
-			go commRunWS(c, ob, ctx, ob.wg)
+	if len(errs) > 0 {
+		if strict {
+			cancel()
+			ob.stopComms()
+			close(done)
+			return &StartError{Errors: errs}
+		}
+		for _, err := range errs {
+			ob.Logger.Errorf("%v", err)
 		}
 	}
 
-	if ob.Config.Comm.WSReverse != nil {
-		for _, c := range ob.Config.Comm.WSReverse {
-			ob.wg.Add(1)
-			go commRunWSReverse(c, ob, ctx, ob.wg)
-		}
+	ob.health.setRunning(true)
+	ob.Logger.Infof("OneBot 已启动")
+	go func() {
+		<-ctx.Done()
+		ob.health.setRunning(false)
+		ob.stopComms()
+		ob.Logger.Infof("OneBot 已关闭")
+		close(done)
+	}()
+	if len(errs) > 0 {
+		return &StartError{Errors: errs}
 	}
+	return nil
 }
 
-func (ob *OneBot) startHeartbeat(ctx context.Context) {
-	if !ob.Config.Heartbeat.Enabled {
-		return
+// running 返回 OneBot 实例是否已启动且尚未完全停止.
+func (ob *OneBot) running() bool {
+	ob.runLock.Lock()
+	defer ob.runLock.Unlock()
+	if ob.done == nil {
+		return false
+	}
+	select {
+	case <-ob.done:
+		return false
+	default:
+		return true
 	}
+}
 
-	if ob.Config.Heartbeat.Interval == 0 {
-		ob.Logger.Errorf("心跳间隔必须大于 0")
-		return
+type namedCommStarter struct {
+	name   string
+	config interface{}
+	start  commStarter
+}
+
+// commStarters 根据配置列出需要启动的通信方式和其它后台组件.
+func (ob *OneBot) commStarters(config *Config) []namedCommStarter {
+	starters := make([]namedCommStarter, 0)
+	add := func(name string, config interface{}, start commStarter) {
+		starters = append(starters, namedCommStarter{name, config, start})
+	}
+	hostPort := func(host string, port uint16) string {
+		return fmt.Sprintf("%s:%d", host, port)
+	}
+
+	for _, c := range config.Comm.HTTP {
+		c := c
+		add(componentName(CommMethodHTTP, hostPort(c.Host, c.Port)), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartHTTP(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.HTTPWebhook {
+		c := c
+		add(componentName(CommMethodHTTPWebhook, c.URL), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartHTTPWebhook(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.WS {
+		c := c
+		add(componentName(CommMethodWS, hostPort(c.Host, c.Port)), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartWS(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.WSReverse {
+		c := c
+		add(componentName(CommMethodWSReverse, c.URL), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartWSReverse(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.V11.HTTP {
+		c := c
+		add(componentName(CommMethodV11HTTP, hostPort(c.Host, c.Port)), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartV11HTTP(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.V11.WS {
+		c := c
+		add(componentName(CommMethodV11WS, hostPort(c.Host, c.Port)), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartV11WS(c, ob, ctx, wg)
+		})
+	}
+	for _, c := range config.Comm.V11.WSReverse {
+		c := c
+		add(componentName(CommMethodV11WSReverse, c.URL), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartV11WSReverse(c, ob, ctx, wg)
+		})
+	}
+
+	if c := config.Heartbeat; c.Enabled {
+		add("heartbeat", c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return ob.startHeartbeat(c, ctx, wg)
+		})
+	}
+	if c := config.Metrics; c.Enabled {
+		add("metrics "+hostPort(c.Host, c.Port), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartMetrics(c, ob, ctx, wg)
+		})
+	}
+	if c := config.Health; c.Enabled {
+		add("health "+hostPort(c.Host, c.Port), c, func(ctx context.Context, wg *sync.WaitGroup) error {
+			return commStartHealth(c, ob, ctx, wg)
+		})
+	}
+	return starters
+}
+
+// startComm 启动一个通信方式或其它后台组件, 成功时记录并返回其句柄.
+func (ob *OneBot) startComm(ctx context.Context, s namedCommStarter) (*commHandle, error) {
+	ob.commsLock.Lock()
+	_, exists := ob.comms[s.name]
+	ob.commsLock.Unlock()
+	if exists {
+		return nil, fmt.Errorf("%v 重复配置", s.name)
+	}
+
+	h := &commHandle{name: s.name, config: s.config, starter: s, wg: &sync.WaitGroup{}}
+	ctx, h.cancel = context.WithCancel(context.WithValue(ctx, commHandleKey{}, h))
+	if err := s.start(ctx, h.wg); err != nil {
+		h.stop()
+		return nil, err
+	}
+	ob.commsLock.Lock()
+	defer ob.commsLock.Unlock()
+	if ob.comms == nil {
+		// stopped while starting
+		go h.stop()
+		return nil, fmt.Errorf("%v 启动失败, OneBot 已停止", s.name)
+	}
+	ob.comms[s.name] = h
+	return h, nil
+}
+
+// stopComms 停止所有运行中的通信方式和其它后台组件, 并等待其完全停止.
+func (ob *OneBot) stopComms() {
+	ob.commsLock.Lock()
+	comms := ob.comms
+	ob.comms = nil
+	ob.commsLock.Unlock()
+
+	for _, h := range comms {
+		h.cancel()
+	}
+	for _, h := range comms {
+		h.wg.Wait()
+	}
+}
+
+func (ob *OneBot) setRunError(err error) {
+	ob.runErrLock.Lock()
+	if ob.runErr == nil {
+		ob.runErr = err
+	}
+	ob.runErrLock.Unlock()
+}
+
+// GetConfig 获取 OneBot 实例当前的配置, 可在 ApplyConfig 执行期间从其它 goroutine 安全调用.
+//
+// 返回的配置不应被修改, 如需修改请复制后通过 ApplyConfig 应用.
+func (ob *OneBot) GetConfig() *Config {
+	ob.configRW.RLock()
+	defer ob.configRW.RUnlock()
+	return ob.Config
+}
+
+func (ob *OneBot) setConfig(config *Config) {
+	ob.configRW.Lock()
+	ob.Config = config
+	ob.configRW.Unlock()
+}
+
+// GetUserAgent 获取 OneBot 实例的 User-Agent.
+func (ob *OneBot) GetUserAgent() string {
+	return fmt.Sprintf("OneBot/%v LibOneBot/%v", OneBotVersion, Version)
+}
+
+func (ob *OneBot) startHeartbeat(c ConfigHeartbeat, ctx context.Context, wg *sync.WaitGroup) error {
+	if c.Interval == 0 {
+		return errors.New("心跳启动失败, 心跳间隔必须大于 0")
 	}
 
-	ob.wg.Add(1)
+	wg.Add(1)
 	go func() {
-		defer ob.wg.Done()
+		defer wg.Done()
 
-		ticker := time.NewTicker(time.Duration(ob.Config.Heartbeat.Interval) * time.Millisecond)
+		ticker := time.NewTicker(time.Duration(c.Interval) * time.Millisecond)
 		defer ticker.Stop()
 
 		ob.Logger.Infof("心跳开始")
@@ -179,7 +543,7 @@ func (ob *OneBot) startHeartbeat(ctx context.Context) {
 			select {
 			case <-ticker.C:
 				ob.Logger.Debugf("扑通")
-				event := MakeHeartbeatMetaEvent(time.Now(), int64(ob.Config.Heartbeat.Interval))
+				event := MakeHeartbeatMetaEvent(time.Now(), int64(c.Interval))
 				ob.Push(&event)
 			case <-ctx.Done():
 				ob.Logger.Infof("心跳停止")
@@ -187,4 +551,5 @@ func (ob *OneBot) startHeartbeat(ctx context.Context) {
 			}
 		}
 	}()
+	return nil
 }
@@ -1,7 +1,10 @@
 package libonebot
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
 // HandleFunc 将一个函数注册为动作处理器.
@@ -36,13 +39,30 @@ func (ob *OneBot) CallAction(action string, params map[string]interface{}) Respo
 }
 
 func (ob *OneBot) handleRequest(r *Request) (resp Response) {
-	ob.Logger.Debugf("动作请求: %+v", r)
+	logger := ob.requestLogger(r)
+	logger.Debugf("动作请求参数: %v", ob.ParamRedactor.Redact(r.Params.Value()))
+	start := time.Now()
+	ctx, span := ob.Tracer.Start(r.Context(), "onebot.handle_action")
+	r.ctx = ctx
+	span.SetAttribute("onebot.action", r.Action)
+	if comm, ok := commMethodLabels[r.Comm.Method]; ok {
+		span.SetAttribute("onebot.comm", comm)
+	}
+	defer func() {
+		ob.Metrics.observeAction(r.Action, resp.RetCode, time.Since(start).Seconds())
+		ob.audit(r, resp, start)
+		span.SetAttribute("onebot.retcode", resp.RetCode)
+		if resp.Status != statusOK {
+			span.SetError(errors.New(resp.Message))
+		}
+		span.End()
+	}()
 	resp.Echo = r.Echo
 	w := ResponseWriter{resp: &resp}
 
 	if ob.actionHandler == nil {
 		err := fmt.Errorf("动作处理器未设置")
-		ob.Logger.Warn(err)
+		logger.Warnf("%v", err)
 		w.WriteFailed(RetCodeUnsupportedAction, err)
 		return
 	}
@@ -53,35 +73,58 @@ func (ob *OneBot) handleRequest(r *Request) (resp Response) {
 		return
 	}
 
-	ob.Logger.Debugf("动作请求 `%v` 开始处理", r.Action)
+	r.segments = ob.Segments
+	logger.Debugf("动作请求 `%v` 开始处理", r.Action)
 	ob.actionHandler.HandleAction(w, r)
 	if resp.Status == statusOK {
-		ob.Logger.Infof("动作请求 `%v` 处理成功", r.Action)
+		logger.Infof("动作请求 `%v` 处理成功", r.Action)
 	} else if resp.Status == statusFailed {
-		ob.Logger.Errorf("动作请求 `%v` 处理失败, 错误: %v", r.Action, resp.Message)
+		logger.Errorf("动作请求 `%v` 处理失败, 错误: %v", r.Action, resp.Message)
 	} else {
 		err := fmt.Errorf("动作处理器没有正确设置响应状态")
-		ob.Logger.Warn(err)
+		logger.Warnf("%v", err)
 		w.WriteFailed(RetCodeBadHandler, err)
 	}
 	return
 }
 
-func (ob *OneBot) decodeAndHandleRequest(actionBytes []byte, isBinary bool, comm RequestComm) Response {
+// decodeRequest 解码动作请求, ctx 带有通信方式提供的调用链路上下文 (如 HTTP 请求头中的 traceparent),
+// 动作请求中没有 traceparent 字段时, 作为动作请求的 context.
+func (ob *OneBot) decodeRequest(ctx context.Context, actionBytes []byte, isBinary bool, comm RequestComm) (Request, error) {
+	_, span := ob.Tracer.Start(ctx, "onebot.decode_request")
+	defer span.End()
 	request, err := decodeRequest(actionBytes, isBinary, comm)
+	if err != nil {
+		span.SetError(err)
+		return request, err
+	}
+	span.SetAttribute("onebot.action", request.Action)
+	if request.ctx == nil {
+		request.ctx = ctx
+	}
+	return request, nil
+}
+
+// decodeAndHandleRequest 解码并处理动作请求, 返回动作请求的 context (用于编码响应) 和动作响应.
+func (ob *OneBot) decodeAndHandleRequest(ctx context.Context, actionBytes []byte, isBinary bool, comm RequestComm) (context.Context, Response) {
+	request, err := ob.decodeRequest(ctx, actionBytes, isBinary, comm)
 	if err != nil {
 		err := fmt.Errorf("动作请求解析失败, 错误: %v", err)
-		ob.Logger.Warn(err)
-		return failedResponse(RetCodeBadRequest, err)
+		ob.Logger.Warnf("%v", err)
+		return ctx, failedResponse(RetCodeBadRequest, err)
 	}
-	return ob.handleRequest(&request)
+	ctx = request.Context()
+	return ctx, ob.handleRequest(&request)
 }
 
-func (ob *OneBot) encodeResponse(resp Response, isBinary bool) ([]byte, error) {
+func (ob *OneBot) encodeResponse(ctx context.Context, resp Response, isBinary bool) ([]byte, error) {
+	_, span := ob.Tracer.Start(ctx, "onebot.encode_response")
+	defer span.End()
 	respBytes, err := resp.encode(isBinary)
 	if err != nil {
+		span.SetError(err)
 		err := fmt.Errorf("动作响应编码失败, 错误: %v", err)
-		ob.Logger.Warn(err)
+		ob.Logger.Warnf("%v", err)
 		respBytes, _ = failedResponse(RetCodeBadHandler, err).encode(isBinary)
 	}
 	return respBytes, err
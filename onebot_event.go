@@ -1,6 +1,12 @@
 package libonebot
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
 
 // Push 向与 OneBot 实例连接的接受端推送一个事件.
 func (ob *OneBot) Push(event AnyEvent) bool {
@@ -13,15 +19,34 @@ func (ob *OneBot) PushWithSelf(event AnyEvent, self *Self) bool {
 		ob.Logger.Errorf("事件为空")
 		return false
 	}
+	_, span := ob.Tracer.Start(context.Background(), "onebot.push_event")
+	defer span.End()
 	if err := event.tryFixUp(self); err != nil {
 		ob.Logger.Errorf("事件无效, 错误: %v", err)
+		ob.Metrics.eventDropped(event.Name(), "invalid")
+		span.SetError(err)
 		return false
 	}
+	name := event.Name()
+	span.SetAttribute("onebot.event", name)
+	event, dropReason := ob.intercept(event, self)
+	if event == nil {
+		ob.Metrics.eventDropped(name, dropReason)
+		span.SetError(errors.New("事件被拦截器丢弃"))
+		return false
+	}
+	if holder, ok := event.(messageEventHolder); ok {
+		if e := holder.messageEvent(); e.AltMessage == "" && ob.AltMessageRenderer != nil {
+			e.AltMessage = ob.AltMessageRenderer.Render(e.Message)
+		}
+	}
 	ob.Logger.Debugf("事件: %#v", event)
 
 	eventBytes, err := json.Marshal(event)
 	if err != nil {
 		ob.Logger.Errorf("事件序列化失败, 错误: %v", err)
+		ob.Metrics.eventDropped(event.Name(), "marshal_failed")
+		span.SetError(err)
 		return false
 	}
 
@@ -29,15 +54,17 @@ func (ob *OneBot) PushWithSelf(event AnyEvent, self *Self) bool {
 	ob.eventListenChansLock.RLock() // use read lock to allow emitting events concurrently
 	defer ob.eventListenChansLock.RUnlock()
 	for _, ch := range ob.eventListenChans {
-		ch <- marshaledEvent{event.Name(), eventBytes, event}
+		ch <- marshaledEvent{event.Name(), eventBytes, event, span.SpanContext()}
 	}
+	ob.Metrics.eventPushed(event.Name())
 	return true
 }
 
 type marshaledEvent struct {
-	name  string
-	bytes []byte
-	raw   AnyEvent
+	name        string
+	bytes       []byte
+	raw         AnyEvent
+	spanContext SpanContext // 推送事件的 Span, 未记录时无效
 }
 
 func (ob *OneBot) openEventListenChan() <-chan marshaledEvent {
@@ -60,3 +87,75 @@ func (ob *OneBot) closeEventListenChan(ch <-chan marshaledEvent) {
 		}
 	}
 }
+
+// EventFilter 用于筛选订阅的事件, 返回 true 表示接收该事件.
+type EventFilter func(event AnyEvent) bool
+
+// EventNameFilter 创建一个按事件名称筛选的 EventFilter.
+//
+// 参数:
+//   names: 事件名称 (如 `message.private`) 或事件类型 (如 `message`), 匹配其中任一即接收
+func EventNameFilter(names ...string) EventFilter {
+	return func(event AnyEvent) bool {
+		name := event.Name()
+		for _, n := range names {
+			if name == n || strings.HasPrefix(name, n+".") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Subscribe 订阅 OneBot 实例推送的事件, 返回接收事件的通道和取消订阅的函数.
+//
+// 与通信方式相同, 事件推送会阻塞直到所有订阅者接收该事件, 因此订阅者应及时从通道读取事件;
+// 不被 filter 接收的事件不会阻塞推送. 取消订阅后通道将被关闭, cancel 可以重复调用.
+//
+// 参数:
+//   filter: 事件筛选函数, 为 nil 时接收所有事件
+func (ob *OneBot) Subscribe(filter EventFilter) (<-chan AnyEvent, func()) {
+	eventChan := ob.openEventListenChan()
+	out := make(chan AnyEvent)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+		for event := range eventChan {
+			if filter != nil && !filter(event.raw) {
+				continue
+			}
+			select {
+			case out <- event.raw:
+			case <-done:
+				// keep draining until the listen chan is closed, so that Push is never blocked by us
+			}
+		}
+	}()
+
+	cancelOnce := &sync.Once{}
+	cancel := func() {
+		cancelOnce.Do(func() {
+			close(done)
+			ob.closeEventListenChan(eventChan)
+		})
+	}
+	return out, cancel
+}
+
+// OnEvent 注册一个事件回调函数, 返回取消注册的函数.
+//
+// 回调函数在单独的 goroutine 中按事件推送顺序依次调用, 调用期间推送将被阻塞, 耗时操作应另起 goroutine.
+//
+// 参数:
+//   filter: 事件筛选函数, 为 nil 时接收所有事件
+//   handler: 事件回调函数
+func (ob *OneBot) OnEvent(filter EventFilter, handler func(event AnyEvent)) func() {
+	events, cancel := ob.Subscribe(filter)
+	go func() {
+		for event := range events {
+			handler(event)
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,166 @@
+package libonebot
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventDeduplicator 是一个丢弃重复事件的事件拦截器, 通过 OneBot.UseEventInterceptor 启用.
+//
+// 事件的去重键优先使用 KeyFunc 返回的平台原生去重键 (通常为机器人平台原生的消息 ID 或更新 ID),
+// 其次使用被 OneBot 实现替换为平台原生 ID 的事件 id 字段 (构造事件时自动生成的 ID 各不相同, 不作为去重键),
+// 最后在 Fingerprint 为 true 时使用去除 id 和 time 字段后的事件内容指纹, 此时内容相同的不同事件 (如用户连续发送的相同消息) 也会被视为重复,
+// 都没有时不做去重. 去重键按机器人自身标识区分, 元事件不做去重.
+// 在时间窗口内出现过的去重键对应的事件将被丢弃, 运行指标中的丢弃原因为 duplicate,
+// 最多记录指定数量的去重键, 超出时淘汰最早的记录.
+type EventDeduplicator struct {
+	// 获取事件的平台原生去重键, 可为 nil
+	KeyFunc func(event AnyEvent, self *Self) string
+	// 没有平台原生去重键时是否使用事件内容指纹去重
+	Fingerprint bool
+
+	window   time.Duration
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front: newest, back: oldest
+	lock     *sync.Mutex
+}
+
+type dedupeEntry struct {
+	key    string
+	seenAt time.Time
+}
+
+// NewEventDeduplicator 创建一个新的 EventDeduplicator 对象.
+//
+// 参数:
+//   window: 去重时间窗口, 必须大于 0
+//   capacity: 最多记录的去重键数量, 0 表示不限制
+func NewEventDeduplicator(window time.Duration, capacity int) *EventDeduplicator {
+	if window <= 0 {
+		panic("去重时间窗口必须大于 0")
+	}
+	return &EventDeduplicator{
+		window:   window,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		lock:     &sync.Mutex{},
+	}
+}
+
+// Intercept 为 EventDeduplicator 实现 EventInterceptor 接口.
+func (d *EventDeduplicator) Intercept(event AnyEvent, self *Self) AnyEvent {
+	if strings.HasPrefix(event.Name(), EventTypeMeta+".") {
+		return event
+	}
+	key := d.dedupeKey(event, self)
+	if key == "" {
+		return event // no key, let it through
+	}
+	if d.seen(key, time.Now()) {
+		return nil
+	}
+	return event
+}
+
+// Len 获取当前记录的去重键数量.
+func (d *EventDeduplicator) Len() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.evictExpired(time.Now())
+	return d.order.Len()
+}
+
+func (d *EventDeduplicator) dedupeKey(event AnyEvent, self *Self) string {
+	selfKey := ""
+	if self != nil {
+		selfKey = self.Platform + "/" + self.UserID
+	}
+	if d.KeyFunc != nil {
+		if key := d.KeyFunc(event, self); key != "" {
+			return selfKey + "/key:" + key
+		}
+	}
+	if holder, ok := event.(eventIDHolder); ok {
+		if id, generated := holder.eventID(); id != "" && !generated {
+			return selfKey + "/id:" + id
+		}
+	}
+	if d.Fingerprint {
+		if fingerprint := eventFingerprint(event); fingerprint != "" {
+			return selfKey + "/fp:" + fingerprint
+		}
+	}
+	return ""
+}
+
+// eventFingerprint 计算事件内容的指纹, 忽略由 LibOneBot 生成的 id 和 time 字段.
+func eventFingerprint(event AnyEvent) string {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return ""
+	}
+	delete(m, "id")
+	delete(m, "time")
+	delete(m, "alt_message") // may or may not be generated yet
+	b, err = json.Marshal(m) // map keys are sorted, so the result is canonical
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// dropReason 为 EventDeduplicator 实现 eventDropReasoner 接口.
+func (d *EventDeduplicator) dropReason() string {
+	return "duplicate"
+}
+
+// eventIDHolder 由 Event 及嵌入了 Event 的事件类型实现.
+type eventIDHolder interface {
+	// eventID 返回事件 ID 及其是否为构造事件时自动生成的 ID.
+	eventID() (string, bool)
+}
+
+func (e *Event) eventID() (string, bool) {
+	return e.ID, e.ID == e.generatedID
+}
+
+// seen 记录去重键, 返回该去重键是否已在时间窗口内出现过.
+func (d *EventDeduplicator) seen(key string, now time.Time) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.evictExpired(now)
+	if _, ok := d.entries[key]; ok {
+		return true
+	}
+	d.entries[key] = d.order.PushFront(&dedupeEntry{key, now})
+	if d.capacity > 0 && d.order.Len() > d.capacity {
+		d.remove(d.order.Back())
+	}
+	return false
+}
+
+func (d *EventDeduplicator) evictExpired(now time.Time) {
+	for e := d.order.Back(); e != nil; e = d.order.Back() {
+		if now.Sub(e.Value.(*dedupeEntry).seenAt) < d.window {
+			break
+		}
+		d.remove(e)
+	}
+}
+
+func (d *EventDeduplicator) remove(e *list.Element) {
+	d.order.Remove(e)
+	delete(d.entries, e.Value.(*dedupeEntry).key)
+}
@@ -1,15 +1,28 @@
 package libonebot_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	libob "github.com/botuniverse/go-libonebot"
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
-func Example_1() {
+func Example_nothing() {
 	// 示例: 什么都不做的 OneBot 实现
 
 	// 创建空 Config
@@ -27,13 +40,22 @@ func Example_1() {
 
 var ob *libob.OneBot
 
-func Example_2() {
-	// 示例: 修改和使用 Logger
-	ob.Logger.SetLevel(logrus.InfoLevel)
+func Example_logger() {
+	// 示例: 替换和使用 Logger
+
+	// 使用自己配置的 logrus Logger, 在 Go 1.21 及以上版本中也可以通过 NewSlogLogger 使用 log/slog
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	ob.Logger = libob.NewLogrusLogger(logger)
 	ob.Logger.Infof("这是一个 INFO 日志")
+	ob.Logger.WithFields(libob.LogFields{"user_id": "10001"}).Infof("这是一个带有结构化字段的 INFO 日志")
+
+	// 动作请求日志中的 access_token, message 等参数默认被脱敏, 可以修改脱敏规则
+	ob.ParamRedactor.Add("file_url")
+	ob.ParamRedactor.Remove("message")
 }
 
-func Example_3() {
+func Example_extendConfig() {
 	// 示例: 扩展 Config 和 OneBot 类型
 
 	type MyConfig struct {
@@ -59,21 +81,26 @@ func Example_3() {
 		OneBot: libob.NewOneBot(Impl, self, &config.Config),
 		config: config,
 	}
+	_ = ob
 }
 
 const PlatformPrefix = "myplat"
 
-func Example_4() {
+func Example_actionMux() {
 	// 示例: 使用 ActionMux 注册动作处理器
 
 	mux := libob.NewActionMux()
 
 	// 注册 get_status 动作处理函数
 	mux.HandleFunc(libob.ActionGetStatus, func(w libob.ResponseWriter, r *libob.Request) {
-		w.WriteData(map[string]interface{}{
-			"good":                            true,
-			"online":                          true,
-			PlatformPrefix + "special_status": "元气满满", // 扩展动作响应
+		w.WriteData(libob.Status{
+			Good: true,
+			Bots: []libob.BotStatus{
+				{Self: *ob.Self, Online: true},
+			},
+			Extended: libob.ExtendedFields{
+				PlatformPrefix + ".special_status": "元气满满", // 扩展动作响应
+			},
 		})
 	})
 
@@ -88,7 +115,7 @@ func Example_4() {
 
 var mux *libob.ActionMux
 
-func Example_5() {
+func Example_paramGetter() {
 	// 示例: 使用 ParamGetter 获取动作参数
 	mux.HandleFunc(libob.ActionGetUserInfo, func(w libob.ResponseWriter, r *libob.Request) {
 		p := libob.NewParamGetter(w, r)
@@ -96,17 +123,18 @@ func Example_5() {
 		if !ok {
 			return
 		}
-		nocache, ok := p.GetBool(PlatformPrefix + ".nocache") // 获取扩展参数
-		w.WriteData(map[string]interface{}{
-			"user_id":  userID,
-			"nickname": userID,
+		nocache, _ := p.GetBool(PlatformPrefix + ".nocache") // 获取扩展参数
+		_ = nocache
+		w.WriteData(libob.UserInfo{
+			UserID:   userID,
+			UserName: userID,
 		})
 	})
 }
 
 var lastMessageID = uint64(0)
 
-func Example_6() {
+func Example_pushEvent() {
 	// 示例: 构造并推送事件
 
 	// 生成或获取消息 ID
@@ -116,15 +144,15 @@ func Example_6() {
 		libob.MentionSegment("some_user"),
 		libob.TextSegment(" 你好啊～"),
 	}
-	// 构造消息的替代表示
-	alt_message := "@some_user 你好啊～"
+	// 构造消息的替代表示, 留空则在推送时由 ob.AltMessageRenderer 自动生成
+	alt_message := ""
 	// 构造事件对象
 	event := libob.MakePrivateMessageEvent(time.Now(), messageID, message, alt_message, "sender_id")
 	// 推送事件
 	ob.Push(&event)
 }
 
-func Example_7() {
+func Example_extendEvent() {
 	// 示例: 扩展标准事件
 
 	type MyGroupMessageEvent struct {
@@ -141,7 +169,7 @@ func Example_7() {
 	ob.Push(&event)
 }
 
-func Example_8() {
+func Example_multiSelf() {
 	// 示例: 多机器人账号复用 OneBot 对象
 
 	config := &libob.Config{ /* ... */ }
@@ -166,3 +194,1097 @@ func Example_8() {
 	event2 := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "friend_id")
 	ob.PushWithSelf(&event2, &libob.Self{Platform: "myplat1", UserID: "bot_id_2"})
 }
+
+func Example_fragmentedFile() {
+	// 示例: 使用 FragmentedFileManager 处理分片上传/获取文件
+
+	storage := libob.NewMemoryFileStorage()                            // 或自行实现 FileStorage 接口
+	manager := libob.NewFragmentedFileManager(storage, 10*time.Minute) // 10 分钟无活动的上传会话将被丢弃
+	defer manager.Close()
+
+	mux := libob.NewActionMux()
+	mux.HandleImplementation(manager) // 注册 upload_file_fragmented 和 get_file_fragmented 动作
+
+	// 也可以直接调用, 分两片上传 "hello, world", 结束阶段校验 SHA256
+	upload := func(sha256Hex string) error {
+		resp, _ := manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStagePrepare, Name: "a.txt", TotalSize: 12})
+		fileID := resp.(libob.UploadFileResponse).FileID
+		manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStageTransfer, FileID: fileID, Offset: 7, Data: []byte("world")})
+		manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStageTransfer, FileID: fileID, Offset: 0, Data: []byte("hello, ")})
+		_, err := manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStageFinish, FileID: fileID, SHA256: sha256Hex})
+		return err
+	}
+	fmt.Println(upload(strings.Repeat("0", 64)), libob.RetCodeFromError(upload(strings.Repeat("0", 64))) == libob.RetCodeLogicError)
+	fmt.Println(upload("09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b"))
+
+	// 超出文件范围和超过单个分片上限的请求返回错误
+	resp, _ := manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStagePrepare, Name: "b.txt", TotalSize: 12})
+	_, err := manager.UploadFileFragmented(nil, libob.UploadFileFragmentedParams{Stage: libob.FragmentedStageTransfer, FileID: resp.(libob.UploadFileResponse).FileID, Offset: math.MaxInt64, Data: []byte("x")})
+	fmt.Println(err)
+	fileID, _ := storage.StoreFile(libob.FileInfo{Name: "c.txt", TotalSize: 5}, strings.NewReader("hello"))
+	_, err = manager.GetFileFragmented(nil, libob.GetFileFragmentedParams{Stage: libob.FragmentedStageTransfer, FileID: fileID, Offset: 1, Size: math.MaxInt64})
+	fmt.Println(err)
+	data, _ := manager.GetFileFragmented(nil, libob.GetFileFragmentedParams{Stage: libob.FragmentedStageTransfer, FileID: fileID, Offset: 1, Size: 100})
+	fmt.Println(string(data.(libob.GetFileFragmentedTransferResponse).Data))
+
+	// Output:
+	// 文件 SHA256 校验和不匹配 true
+	// <nil>
+	// 分片超出文件范围
+	// 分片大小超过上限 16777216 字节
+	// ello
+}
+
+type MyImpl struct{}
+
+func (impl *MyImpl) SendMessage(r *libob.Request, params libob.SendMessageParams) (libob.SendMessageResponse, error) {
+	if params.DetailType != "private" {
+		return libob.SendMessageResponse{}, libob.NewActionError(libob.RetCodeUnsupportedParam, fmt.Errorf("不支持的 `detail_type`"))
+	}
+	// 调用机器人平台 API 发送 params.Message ...
+	return libob.SendMessageResponse{MessageID: "message_id", Time: float64(time.Now().Unix())}, nil
+}
+
+func (impl *MyImpl) GetSelfInfo(r *libob.Request, params libob.GetSelfInfoParams) (libob.SelfInfo, error) {
+	return libob.SelfInfo{UserID: "id_of_bot", UserName: "bot"}, nil
+}
+
+func Example_implementation() {
+	// 示例: 通过类型化的动作实现接口注册动作处理器
+
+	mux := libob.NewActionMux()
+	// 只注册 MyImpl 实际实现了的 send_message 和 get_self_info 动作
+	mux.HandleImplementation(&MyImpl{})
+	ob.Handle(mux)
+}
+
+func Example_decodeParams() {
+	// 示例: 将动作参数解码到自定义的参数结构体
+
+	type SetSlowModeParams struct {
+		GroupID  string               `json:"group_id"`
+		Interval int16                `json:"myplat.interval"`        // 超出 int16 取值范围时返回错误
+		Level    uint8                `json:"myplat.level,omitempty"` // 可选参数
+		Extended libob.ExtendedFields `json:"-"`                      // 其它扩展参数
+		cache    map[string]string    // 未导出的字段被忽略
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"group_id": "10001", "myplat.interval": 30, "myplat.level": 2},
+		{"group_id": "10001", "myplat.interval": 40000},
+		{"group_id": "10001", "myplat.interval": 30, "myplat.level": -1},
+	} {
+		var p SetSlowModeParams
+		err := libob.DecodeParams(libob.EasierMapFromMap(params), &p)
+		fmt.Println(p.Interval, p.Level, p.cache == nil, libob.RetCodeFromError(err) == libob.RetCodeBadParam)
+	}
+
+	// Output:
+	// 30 2 true false
+	// 0 0 true true
+	// 30 0 true true
+}
+
+func Example_validateMessage() {
+	// 示例: 检查消息并获取消息段数据
+
+	message := libob.Message{
+		libob.TextSegment("看看这个位置: "),
+		libob.LocationSegment(31.032315, 121.447127, "上海交通大学闵行校区", "中国上海市闵行区东川路800号"),
+		libob.CustomSegment(libob.SegTypeImage, map[string]interface{}{}), // 缺少 file_id
+	}
+	if err := message.Validate(); err != nil {
+		fmt.Println(libob.RetCodeFromError(err), err)
+	}
+	// 与按消息段类型注册表检查的结果相同
+	if err := libob.NewSegmentRegistry().Validate(message); err != nil {
+		fmt.Println(libob.RetCodeFromError(err), err)
+	}
+
+	location, _ := message[1].AsLocation()
+	fmt.Println(location.Title)
+
+	// Output:
+	// 10006 第 3 个消息段无效: 消息段 `image` 数据无效: `file_id` 字段不存在
+	// 10006 第 3 个消息段无效: 消息段 `image` 数据无效: `file_id` 字段不存在
+	// 上海交通大学闵行校区
+}
+
+func Example_registerSegment() {
+	// 示例: 注册扩展消息段类型
+
+	ob.Segments.Register(libob.SegmentType{
+		Name: PlatformPrefix + ".face",
+		Fields: []libob.SegmentField{
+			{Name: "id", Type: libob.SegFieldInt, Required: true},
+		},
+		AltText: func(s libob.Segment) string {
+			return "[表情]"
+		},
+	})
+	ob.Segments.Unregister(libob.SegTypeVoice) // 移除不支持的标准消息段类型
+
+	mux := libob.NewActionMux()
+	mux.HandleFunc(libob.ActionSendMessage, func(w libob.ResponseWriter, r *libob.Request) {
+		p := libob.NewParamGetter(w, r)
+		// 消息中包含未注册的消息段类型或无效的消息段数据时, 将自动返回 10005, 10006 或 10007 错误
+		message, ok := p.GetMessage("message")
+		if !ok {
+			return
+		}
+		_ = message
+	})
+	ob.Handle(mux)
+}
+
+func Example_supportedSegments() {
+	// 示例: 应用端查询 OneBot 实现支持的消息段类型
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	ob.Segments.Register(libob.SegmentType{Name: PlatformPrefix + ".face"})
+	ob.Segments.Unregister(libob.SegTypeVoice)
+	// ActionMux 自动处理 libonebot.get_supported_segments 动作, 返回 ob.Segments 中注册的消息段类型
+	ob.Handle(libob.NewActionMux())
+
+	resp := ob.CallAction(libob.ActionGetSupportedSegments, nil)
+	fmt.Println(resp.Status, resp.Data)
+
+	// Output:
+	// ok [audio file image location mention mention_all myplat.face reply text video]
+}
+
+func Example_altMessage() {
+	// 示例: 渲染消息的替代表示
+
+	renderer := libob.NewAltMessageRenderer(nil)
+	message := libob.Message{
+		libob.ReplySegment("message_id", "alice"),
+		libob.MentionSegment("bob"),
+		libob.TextSegment(" 看这里 "),
+		libob.ImageSegment("file_id"),
+		libob.LocationSegment(31.032315, 121.447127, "上海交通大学闵行校区", "中国上海市闵行区东川路800号"),
+	}
+	fmt.Println(renderer.Render(message))
+
+	// 覆盖提及消息段的渲染方式
+	renderer.SetSegmentRenderer(libob.SegTypeMention, func(s libob.Segment) string {
+		userID, _ := s.Data.GetString("user_id")
+		return "@" + strings.ToUpper(userID)
+	})
+	fmt.Println(renderer.Render(message))
+
+	// Output:
+	// [回复 @alice]@bob 看这里 [图片][位置: 上海交通大学闵行校区]
+	// [回复 @alice]@BOB 看这里 [图片][位置: 上海交通大学闵行校区]
+}
+
+func Example_cqCode() {
+	// 示例: 与 OneBot 11 CQ 码相互转换
+
+	message, err := libob.ParseCQCode("[CQ:reply,id=123][CQ:at,qq=10001] 看看 &#91;这个&#93;[CQ:image,file=abc.jpg,cache=0][CQ:face,id=178][CQ:shake]")
+	if err != nil {
+		return
+	}
+	for _, s := range message {
+		fmt.Println(s.Type, s.Data.Value())
+	}
+	fmt.Println(message.ToCQCode())
+
+	// Output:
+	// reply map[message_id:123 user_id:]
+	// mention map[user_id:10001]
+	// text map[text: 看看 [这个]]
+	// image map[cq.cache:0 file_id:abc.jpg]
+	// qq.face map[id:178]
+	// shake map[]
+	// [CQ:reply,id=123][CQ:at,qq=10001] 看看 &#91;这个&#93;[CQ:image,cache=0,file=abc.jpg][CQ:face,id=178][CQ:shake]
+}
+
+func Example_v11() {
+	// 示例: 同时向 OneBot 11 应用提供兼容的 API
+
+	config := &libob.Config{}
+	config.Comm.V11.HTTP = []libob.ConfigCommV11HTTP{{Host: "127.0.0.1", Port: 5700}}
+	config.Comm.V11.WSReverse = []libob.ConfigCommWSReverse{{URL: "ws://127.0.0.1:8080/onebot/v11/ws", ReconnectInterval: 3000}}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	// OneBot 11 的 send_private_msg 等动作将被转换为 OneBot 12 动作交由 ob.Handle 注册的处理器处理,
+	// 通过 ob.Push 推送的事件将被转换为 OneBot 11 事件
+	mux := libob.NewActionMux()
+	mux.HandleImplementation(&MyImpl{})
+	ob.Handle(mux)
+	go ob.Run()
+}
+
+// freePorts 返回 n 个当前空闲且互不相同的本地端口, 供需要实际监听的示例使用.
+func freePorts(n int) []uint16 {
+	ports := make([]uint16, n)
+	for i := range ports {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			panic(err)
+		}
+		defer listener.Close() // keep listening until all ports are picked
+		ports[i] = uint16(listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports
+}
+
+func Example_v11MessageID() {
+	// 示例: OneBot 11 应用发送消息后, 用返回的整数消息 ID 撤回消息
+
+	port := freePorts(1)[0]
+	config := &libob.Config{}
+	config.Comm.V11.HTTP = []libob.ConfigCommV11HTTP{{Host: "127.0.0.1", Port: port}}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	ob.Logger = libob.DiscardLogger
+	mux := libob.NewActionMux()
+	mux.HandleFunc(libob.ActionSendMessage, func(w libob.ResponseWriter, r *libob.Request) {
+		userID, _ := r.Params.GetString("user_id")
+		message, _ := r.Params.GetMessage("message")
+		fmt.Println(libob.ActionSendMessage, userID, message[0].Type, message[0].Data.Value())
+		// 机器人平台的消息 ID 不是整数, 转换为 OneBot 11 动作响应时分配负整数
+		w.WriteData(map[string]interface{}{"message_id": "msg-abc", "time": 0})
+	})
+	mux.HandleFunc(libob.ActionDeleteMessage, func(w libob.ResponseWriter, r *libob.Request) {
+		messageID, _ := r.Params.GetString("message_id")
+		fmt.Println(libob.ActionDeleteMessage, messageID)
+		w.WriteData(nil)
+	})
+	ob.Handle(mux)
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer ob.Shutdown(context.Background())
+
+	call := func(action string, params string) map[string]interface{} {
+		resp, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d/%s", port, action), "application/json", strings.NewReader(params))
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+		defer resp.Body.Close()
+		var result struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result.Data
+	}
+	data := call("send_msg", `{"user_id":10002,"message":[{"type":"at","data":{"qq":1234567890}}]}`)
+	fmt.Println("message_id:", data["message_id"])
+	call("delete_msg", fmt.Sprintf(`{"message_id":%v}`, data["message_id"]))
+	// 未分配过的负整数消息 ID 原样转换
+	call("delete_msg", `{"message_id":-999}`)
+
+	// Output:
+	// send_message 10002 mention map[user_id:1234567890]
+	// message_id: -1
+	// delete_message msg-abc
+	// delete_message -999
+}
+
+func Example_markdown() {
+	// 示例: 将消息转换为 Markdown 和 HTML, 以及从 Markdown 构造消息
+
+	message := libob.Message{
+		libob.ReplySegment("42", "10001"),
+		libob.MentionSegment("10001"),
+		libob.TextSegment(" 1*2 <= 3\n看图: "),
+		libob.ImageSegment("abc"),
+	}
+	fmt.Println(message.ToMarkdown())
+	fmt.Println(message.ToHTML())
+
+	parsed, err := libob.ParseMarkdown(`[@全体成员](mention-all:) 请看 [文档](https://12.onebot.dev) \*注意\*![图片](image:def)`)
+	if err != nil {
+		return
+	}
+	for _, s := range parsed {
+		fmt.Println(s.Type, s.Data.Value())
+	}
+
+	// Output:
+	// [\[回复 @10001\]](reply:42?user_id=10001)[@10001](mention:10001) 1\*2 \<= 3\
+	// 看图: ![\[图片\]](image:abc)
+	// <span class="ob-reply" data-message-id="42" data-user-id="10001">[回复 @10001]</span><span class="ob-mention" data-user-id="10001">@10001</span> 1*2 &lt;= 3<br>看图: <span class="ob-image" data-file-id="abc">[图片]</span>
+	// mention_all map[]
+	// text map[text: 请看 文档 (https://12.onebot.dev) *注意*]
+	// image map[file_id:def]
+}
+
+func Example_messageBuilder() {
+	// 示例: 构造和处理消息
+
+	message := libob.NewMessage().Mention("bot").Text(" 这是一段").Text("比较长的文字").Image("abc").Build()
+
+	// 去除开头对机器人的提及
+	stripped, ok := message.StripLeadingMention("bot")
+	fmt.Println(ok, stripped.ToCQCode())
+
+	// 将图片替换为文字
+	replaced := stripped.ReplaceType(libob.SegTypeImage, func(s libob.Segment) libob.Message {
+		return libob.NewMessage().Text("(图)").Build()
+	})
+	fmt.Println(replaced.ToCQCode(), replaced.Equal(stripped))
+
+	// 拆分为长度不超过 6 的多条消息
+	for _, part := range stripped.Split(6) {
+		fmt.Println(part.ToCQCode())
+	}
+
+	// Output:
+	// true 这是一段比较长的文字[CQ:image,file=abc]
+	// 这是一段比较长的文字(图) false
+	// 这是一段比较
+	// 长的文字
+	// [CQ:image,file=abc]
+}
+
+type EchoArgs struct {
+	Times int                    `cmd:"times"`
+	Image libob.ImageSegmentData `cmd:"image"`
+	Words []string               `cmd:"words"`
+}
+
+func Example_command() {
+	// 示例: 解析命令并分发到处理函数
+
+	router := libob.NewCommandRouter("/")
+	router.Parser.MentionAsPrefix = true
+	router.Handle("echo", func(c *libob.Command, args EchoArgs) error {
+		fmt.Println(c.Mentioned, args.Times, args.Image.FileID, strings.Join(args.Words, "|"))
+		return nil
+	})
+
+	message := libob.NewMessage().Mention("bot").Text(` echo 2`).Image("abc").Text(` "hello world" \"!`).Build()
+	handled, err := router.Dispatch(message, "bot")
+	fmt.Println(handled, err)
+
+	handled, err = router.Dispatch(libob.NewMessage().Text("/echo x").Build(), "bot")
+	fmt.Println(handled, err)
+
+	// Output:
+	// true 2 abc hello world|"!
+	// true <nil>
+	// true 命令 `echo` 参数 `times` 无效: `x` 不是有效的整数
+}
+
+func Example_reply() {
+	// 示例: 回复收到的消息事件
+
+	event := libob.MakeGroupMessageEvent(time.Now(), "msg_1", libob.NewMessage().Text("ping").Build(), "", "group_1", "user_1")
+	params, err := libob.ReplyTo(&event, libob.NewMessage().Text("pong").Build(), true)
+	if err != nil {
+		return
+	}
+	fmt.Println(params.DetailType, params.GroupID, params.Message.ToCQCode())
+	// 可以通过 ob.CallAction(libob.ActionSendMessage, params.ToMap()) 发送, 或直接使用 ob.Reply(&event, message, true)
+
+	// 嵌入了标准消息事件的扩展事件同样可以回复
+	type MyPrivateMessageEvent struct {
+		libob.PrivateMessageEvent
+		Anonymous string `json:"myplat.anonymous"`
+	}
+	myEvent := MyPrivateMessageEvent{
+		PrivateMessageEvent: libob.MakePrivateMessageEvent(time.Now(), "msg_2", libob.NewMessage().Text("ping").Build(), "", "user_2"),
+		Anonymous:           "齐天大圣",
+	}
+	params, err = libob.ReplyTo(&myEvent, libob.NewMessage().Text("pong").Build(), true)
+	fmt.Println(params.DetailType, params.UserID, params.Message.ToCQCode(), err)
+
+	notice := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "user_3")
+	_, err = libob.ReplyTo(&notice, libob.NewMessage().Text("pong").Build(), false)
+	fmt.Println(err)
+
+	// Output:
+	// group group_1 [CQ:reply,id=msg_1,qq=user_1]pong
+	// private user_2 [CQ:reply,id=msg_2,qq=user_2]pong <nil>
+	// 不支持回复 `*libonebot.FriendIncreaseNoticeEvent` 类型的事件
+}
+
+func Example_subscribe() {
+	// 示例: 在进程内订阅 OneBot 实例推送的事件
+
+	// 通过通道接收消息事件
+	events, cancel := ob.Subscribe(libob.EventNameFilter("message"))
+	defer cancel()
+	go func() {
+		for event := range events {
+			fmt.Println("收到消息事件:", event.Name())
+		}
+	}()
+
+	// 通过回调函数接收所有事件
+	cancelLog := ob.OnEvent(nil, func(event libob.AnyEvent) {
+		ob.Logger.Infof("事件 `%v` 已推送", event.Name())
+	})
+	defer cancelLog()
+}
+
+func Example_eventInterceptor() {
+	// 示例: 在推送前修改或丢弃事件
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	ob.UseEventInterceptor("redact", libob.EventInterceptorFunc(func(event libob.AnyEvent, self *libob.Self) libob.AnyEvent {
+		if e, ok := event.(*libob.PrivateMessageEvent); ok {
+			if strings.Contains(e.Message.ExtractText(), "spam") {
+				return nil // 丢弃事件
+			}
+			e.Message = e.Message.ReplaceType(libob.SegTypeImage, func(s libob.Segment) libob.Message {
+				return libob.NewMessage().Text("(图片已隐藏)").Build()
+			})
+		}
+		return event
+	}))
+
+	e1 := libob.MakePrivateMessageEvent(time.Now(), "1", libob.NewMessage().Image("abc").Build(), "", "user_1")
+	fmt.Println(ob.Push(&e1), e1.Message.ExtractText(), e1.AltMessage)
+	e2 := libob.MakePrivateMessageEvent(time.Now(), "2", libob.NewMessage().Text("buy spam").Build(), "", "user_1")
+	fmt.Println(ob.Push(&e2))
+	fmt.Printf("%+v\n", ob.EventInterceptorStats())
+
+	// Output:
+	// true (图片已隐藏) (图片已隐藏)
+	// false
+	// [{Name:redact Seen:2 Replaced:0 Dropped:1}]
+}
+
+func Example_dedupe() {
+	// 示例: 丢弃机器人平台重复投递的事件
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	dedupe := libob.NewEventDeduplicator(5*time.Minute, 10000)
+	// 使用平台原生的消息 ID 作为去重键
+	dedupe.KeyFunc = func(event libob.AnyEvent, self *libob.Self) string {
+		if e, ok := event.(*libob.PrivateMessageEvent); ok {
+			return e.MessageID
+		}
+		return ""
+	}
+	ob.UseEventInterceptor("dedupe", dedupe)
+
+	for i := 0; i < 2; i++ {
+		e := libob.MakePrivateMessageEvent(time.Now(), "msg_1", libob.NewMessage().Text("hello").Build(), "", "user_1")
+		n := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "user_2")
+		n.ID = "update_1" // 将平台原生的更新 ID 设为事件 ID
+		// 没有平台原生去重键, 且事件 ID 为自动生成的事件不做去重
+		f := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "user_3")
+		fmt.Println(ob.Push(&e), ob.Push(&n), ob.Push(&f))
+	}
+	// 内容相同但消息 ID 不同的消息不是重复事件
+	e := libob.MakePrivateMessageEvent(time.Now(), "msg_2", libob.NewMessage().Text("hello").Build(), "", "user_1")
+	fmt.Println(ob.Push(&e), dedupe.Len())
+
+	// 平台没有原生 ID 时, 可以使用事件内容指纹去重
+	dedupe.Fingerprint = true
+	for i := 0; i < 2; i++ {
+		f := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "user_3")
+		fmt.Println(ob.Push(&f))
+	}
+
+	var buf bytes.Buffer
+	ob.Metrics.WriteTo(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "onebot_events_dropped_total{") {
+			fmt.Println(line)
+		}
+	}
+
+	// Output:
+	// true true true
+	// false false true
+	// true 3
+	// true
+	// false
+	// onebot_events_dropped_total{event="message.private",reason="duplicate"} 1
+	// onebot_events_dropped_total{event="notice.friend_increase",reason="duplicate"} 2
+}
+
+func Example_metrics() {
+	// 示例: 输出 Prometheus 文本格式的运行指标
+	// 也可以在配置中开启 metrics, 由 LibOneBot 启动单独的 HTTP 服务器, 或将 ob.Metrics.Handler() 挂载到自己的 HTTP 服务器上
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	mux := libob.NewActionMux()
+	mux.HandleFunc("ping", func(w libob.ResponseWriter, r *libob.Request) {
+		w.WriteData("pong")
+	})
+	ob.Handle(mux)
+
+	ob.CallAction("ping", nil)
+	// 不支持的动作都记为 unknown
+	ob.CallAction("no_such_action", nil)
+	ob.CallAction("another_action", nil)
+	e := libob.MakePrivateMessageEvent(time.Now(), "1", libob.NewMessage().Text("hello").Build(), "", "user_1")
+	ob.Push(&e)
+
+	var buf bytes.Buffer
+	ob.Metrics.WriteTo(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "onebot_action_requests_total{") || strings.HasPrefix(line, "onebot_events_pushed_total{") {
+			fmt.Println(line)
+		}
+	}
+
+	// Output:
+	// onebot_action_requests_total{action="ping",retcode="0"} 1
+	// onebot_action_requests_total{action="unknown",retcode="10002"} 2
+	// onebot_events_pushed_total{event="message.private"} 1
+}
+
+func Example_metricsWebhookURL() {
+	// 示例: HTTP Webhook 推送次数的 url 标签不包含用户信息和查询参数
+
+	delivered := make(chan struct{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+		delivered <- struct{}{}
+	}))
+	defer receiver.Close()
+
+	url := strings.Replace(receiver.URL, "http://", "http://user:password@", 1) + "/events?access_token=secret"
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			HTTPWebhook: []libob.ConfigCommHTTPWebhook{{URL: url}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	ob.Logger = libob.DiscardLogger
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	e := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "10002")
+	ob.Push(&e)
+	<-delivered
+	ob.Shutdown(context.Background()) // wait for the delivery to be recorded
+
+	var buf bytes.Buffer
+	ob.Metrics.WriteTo(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "onebot_webhook_deliveries_total{") {
+			fmt.Println(strings.Replace(line, receiver.URL, "http://receiver", 1))
+		}
+	}
+
+	// Output:
+	// onebot_webhook_deliveries_total{url="http://receiver/events",result="success"} 1
+}
+
+func Example_tracing() {
+	// 示例: 记录动作请求和事件推送的调用链路
+	// 实际使用时可实现 SpanExporter 接口, 将 Span 发送到 OpenTelemetry Collector 等
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	exporter := libob.NewInMemorySpanExporter()
+	ob.Tracer = libob.NewTracer(exporter)
+
+	mux := libob.NewActionMux()
+	mux.HandleFunc(libob.ActionGetSelfInfo, func(w libob.ResponseWriter, r *libob.Request) {
+		// 在动作请求的调用链路下追踪对机器人平台 API 的调用
+		_, span := ob.Tracer.Start(r.Context(), "qq.get_self_info")
+		defer span.End()
+		w.WriteData(libob.SelfInfo{UserID: "10001", UserName: "bot"})
+	})
+	ob.Handle(mux)
+
+	ob.CallAction(libob.ActionGetSelfInfo, nil)
+	for _, span := range exporter.Spans() {
+		fmt.Println(span.Name, span.Attributes["onebot.retcode"], span.SpanContext.Sampled)
+	}
+
+	// Output:
+	// qq.get_self_info <nil> true
+	// onebot.handle_action 0 true
+}
+
+func Example_audit() {
+	// 示例: 记录每个动作请求的审计日志
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	dir, err := os.MkdirTemp("", "audit")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	// 写入 audit.log, 单个文件超过 10 MiB 时轮转, 保留 5 个旧文件
+	sink, err := libob.NewFileAuditSink(filepath.Join(dir, "audit.log"), 10<<20, 5)
+	if err != nil {
+		panic(err)
+	}
+	defer sink.Close()
+	ob.AuditSink = sink
+
+	// 也可以实现 AuditSink 接口, 将审计记录写入其它位置
+	ob.AuditSink = libob.AuditSinkFunc(func(record libob.AuditRecord) error {
+		fmt.Println(record.Comm, record.Action, record.Params, record.RetCode)
+		return sink.WriteAudit(record)
+	})
+}
+
+func Example_auditPrincipal() {
+	// 示例: HTTP Webhook 响应中的动作请求, 审计记录的请求方身份不包含用户信息和查询参数
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"action":"get_supported_actions","params":{}}]`))
+	}))
+	defer receiver.Close()
+
+	url := strings.Replace(receiver.URL, "http://", "http://user:password@", 1) + "/events?access_token=secret"
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			HTTPWebhook: []libob.ConfigCommHTTPWebhook{{URL: url}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	ob.Logger = libob.DiscardLogger
+	audited := make(chan libob.AuditRecord, 1)
+	ob.AuditSink = libob.AuditSinkFunc(func(record libob.AuditRecord) error {
+		audited <- record
+		return nil
+	})
+	ob.Handle(libob.NewActionMux())
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer ob.Shutdown(context.Background())
+	e := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "10002")
+	ob.Push(&e)
+	record := <-audited
+	fmt.Println(record.Comm, record.Action, strings.Replace(record.Principal, receiver.URL, "http://receiver", 1))
+
+	// Output:
+	// http_webhook get_supported_actions http://receiver/events
+}
+
+func Example_auditRotation() {
+	// 示例: 审计日志文件的轮转
+
+	dir, err := os.MkdirTemp("", "audit")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	// 每条记录都超过文件大小上限, 因此每次写入前都轮转, 保留 2 个旧文件
+	sink, err := libob.NewFileAuditSink(path, 1, 2)
+	if err != nil {
+		panic(err)
+	}
+	defer sink.Close()
+	for _, action := range []string{"a", "b", "c", "d"} {
+		fmt.Println(sink.WriteAudit(libob.AuditRecord{Action: action}))
+	}
+	printFiles := func() {
+		for _, name := range []string{"audit.log", "audit.log.1", "audit.log.2", "audit.log.3"} {
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				fmt.Println(name, "不存在")
+				continue
+			}
+			var actions []string
+			for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+				var record libob.AuditRecord
+				json.Unmarshal([]byte(line), &record)
+				actions = append(actions, record.Action)
+			}
+			fmt.Println(name, actions)
+		}
+	}
+	printFiles()
+
+	// 轮转失败时继续写入原来的文件, 并返回错误
+	os.MkdirAll(filepath.Join(dir, "audit.log.rotating", "busy"), 0700)
+	fmt.Println(sink.WriteAudit(libob.AuditRecord{Action: "e"}) != nil)
+	printFiles()
+
+	// Output:
+	// <nil>
+	// <nil>
+	// <nil>
+	// <nil>
+	// audit.log [d]
+	// audit.log.1 [c]
+	// audit.log.2 [b]
+	// audit.log.3 不存在
+	// true
+	// audit.log [d e]
+	// audit.log.1 [c]
+	// audit.log.2 [b]
+	// audit.log.3 不存在
+}
+
+func Example_health() {
+	// 示例: 提供健康检查端点
+
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			// 在 HTTP 通信方式的服务器上提供 /healthz 和 /readyz
+			HTTP: []libob.ConfigCommHTTP{{Host: "127.0.0.1", Port: 5700, Health: true}},
+		},
+		// 或使用单独的健康检查 HTTP 服务器
+		Health: libob.ConfigHealth{Enabled: true, Host: "127.0.0.1", Port: 5710},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	// 机器人平台未登录时, 就绪检查失败
+	ob.ReadinessCheck = func(ctx context.Context) error {
+		return fmt.Errorf("机器人账号未登录")
+	}
+
+	// 也可以直接检查就绪状态
+	report := ob.CheckReadiness(context.Background())
+	fmt.Println(report.Ready, report.Platform.Error)
+
+	// Output:
+	// false 机器人账号未登录
+}
+
+func Example_start() {
+	// 示例: 启动 OneBot 实例并处理启动错误
+
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			HTTP: []libob.ConfigCommHTTP{{Host: "127.0.0.1", Port: 5700}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	// 所有通信方式都启动成功后才返回 nil, 否则返回 *StartError, 例如端口已被占用
+	if err := ob.Start(ctx); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 收到 SIGINT 后停止运行
+	if err := ob.Wait(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func Example_validateConfig() {
+	// 示例: 检查配置
+
+	config := &libob.Config{
+		Heartbeat: libob.ConfigHeartbeat{Enabled: true},
+		Comm: libob.ConfigComm{
+			HTTP: []libob.ConfigCommHTTP{{Host: "0.0.0.0", Port: 5700}},
+			WS:   []libob.ConfigCommWS{{Host: "127.0.0.1", Port: 5700}},
+			WSReverse: []libob.ConfigCommWSReverse{
+				{URL: "ws://127.0.0.1:8080", ReconnectInterval: 5000},
+				{URL: "http://127.0.0.1:8080"},
+			},
+		},
+	}
+	_, err := libob.TryNewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	if configErr, ok := err.(*libob.ConfigError); ok {
+		for _, e := range configErr.Errors {
+			fmt.Println(e.Path, e.Message)
+		}
+	}
+
+	// Output:
+	// heartbeat.interval 必须大于 0
+	// comm.http[0].access_token 监听非本机地址时不能为空
+	// comm.ws[0].port 与 `comm.http[0]` 的监听地址冲突
+	// comm.ws_reverse[1].url 必须使用 WS 或 WSS 协议
+	// comm.ws_reverse[1].reconnect_interval 必须大于 0
+}
+
+func Example_loadConfig() {
+	// 示例: 从配置文件和环境变量加载配置
+
+	type MyConfig struct {
+		libob.Config
+		SelfID string `mapstructure:"self_id"`
+	}
+
+	dir, _ := os.MkdirTemp("", "onebot")
+	defer os.RemoveAll(dir)
+	path := dir + "/config.yaml"
+	os.WriteFile(path, []byte(`
+self_id: "10001"
+comm:
+  ws_reverse:
+    - url: ws://127.0.0.1:8080/onebot/v12/
+  ws:
+    - {}
+  v11:
+    ws:
+      - {}
+`), 0o600)
+	// 环境变量优先于配置文件
+	os.Setenv("ONEBOT_COMM_HTTP_0_PORT", "5701")
+	defer os.Unsetenv("ONEBOT_COMM_HTTP_0_PORT")
+
+	// 配置文件中缺少的字段使用默认值, 但不会启用配置文件中没有的通信方式和心跳
+	config := &MyConfig{}
+	if err := libob.LoadConfig(path, config); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(config.SelfID, config.Heartbeat.Enabled, config.Heartbeat.Interval)
+	fmt.Println(config.Comm.HTTP[0].Host, config.Comm.HTTP[0].Port)
+	fmt.Println(config.Comm.WSReverse[0].URL, config.Comm.WSReverse[0].ReconnectInterval)
+	// OneBot 11 通信方式的默认端口与 OneBot 12 通信方式不同
+	fmt.Println(config.Comm.WS[0].Port, config.Comm.V11.WS[0].Port)
+
+	// 配置文件不存在时将写入带注释的默认配置文件, 并按默认配置加载
+	os.Unsetenv("ONEBOT_COMM_HTTP_0_PORT")
+	defaultConfig := &libob.Config{}
+	if err := libob.LoadConfig(filepath.Join(dir, "default.toml"), defaultConfig); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(defaultConfig.Heartbeat.Enabled, defaultConfig.Comm.HTTP[0].Port)
+	_, err := os.Stat(filepath.Join(dir, "default.toml"))
+	fmt.Println(err)
+
+	// Output:
+	// 10001 false 5000
+	// 127.0.0.1 5701
+	// ws://127.0.0.1:8080/onebot/v12/ 5000
+	// 6700 6701
+	// true 5700
+	// <nil>
+}
+
+func Example_applyConfig() {
+	// 示例: 热重载配置
+
+	config := &libob.Config{}
+	if err := libob.LoadConfig("config.toml", config); err != nil {
+		fmt.Println(err)
+		return
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := ob.Start(ctx); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// 配置文件被修改时自动应用新配置, 只重启配置改变的通信方式, 其它通信方式上的连接不受影响
+	go ob.WatchConfig(ctx, "config.toml", 5*time.Second, nil)
+
+	// 也可以手动应用新配置, 例如添加一个 WebSocket 通信方式
+	newConfig := *ob.GetConfig()
+	newConfig.Comm.WS = append(newConfig.Comm.WS, libob.ConfigCommWS{Host: "127.0.0.1", Port: 6700})
+	if err := ob.ApplyConfig(&newConfig); err != nil {
+		fmt.Println(err)
+	}
+
+	ob.Wait()
+}
+
+func Example_getConfig() {
+	// 示例: 在其它 goroutine 中读取 ApplyConfig 应用的配置
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer ob.Shutdown(context.Background())
+
+	applied := make(chan uint32)
+	go func() {
+		for ob.GetConfig().Heartbeat.Interval == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		applied <- ob.GetConfig().Heartbeat.Interval
+	}()
+	newConfig := &libob.Config{Heartbeat: libob.ConfigHeartbeat{Enabled: true, Interval: 5000}}
+	fmt.Println(ob.ApplyConfig(newConfig))
+	fmt.Println(<-applied)
+
+	// Output:
+	// <nil>
+	// 5000
+}
+
+func Example_applyConfigKeepConnections() {
+	// 示例: 热重载配置时, 配置未改变的通信方式上的连接不受影响
+
+	ports := freePorts(3)
+	wsPort, oldHTTPPort, newHTTPPort := ports[0], ports[1], ports[2]
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			WS:   []libob.ConfigCommWS{{Host: "127.0.0.1", Port: wsPort}},
+			HTTP: []libob.ConfigCommHTTP{{Host: "127.0.0.1", Port: oldHTTPPort}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	ob.Logger = libob.DiscardLogger
+	mux := libob.NewActionMux()
+	mux.HandleFunc("ping", func(w libob.ResponseWriter, r *libob.Request) {
+		w.WriteData("pong")
+	})
+	ob.Handle(mux)
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer ob.Shutdown(context.Background())
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://127.0.0.1:%d", wsPort), nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer conn.Close()
+	pingWS := func() {
+		var resp struct {
+			Data string `json:"data"`
+		}
+		conn.WriteJSON(map[string]interface{}{"action": "ping", "params": map[string]interface{}{}})
+		err := conn.ReadJSON(&resp)
+		fmt.Println("WebSocket:", resp.Data, err)
+	}
+	pingHTTP := func(name string, port uint16) {
+		resp, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d", port), "application/json", strings.NewReader(`{"action":"ping","params":{}}`))
+		if err != nil {
+			fmt.Println("HTTP", name, "不可用")
+			return
+		}
+		resp.Body.Close()
+		fmt.Println("HTTP", name, "可用")
+	}
+	pingWS()
+
+	// 修改 HTTP 端口, 只重启 HTTP 通信方式
+	newConfig := *config
+	newConfig.Comm.HTTP = []libob.ConfigCommHTTP{{Host: "127.0.0.1", Port: newHTTPPort}}
+	fmt.Println(ob.ApplyConfig(&newConfig))
+	pingWS()
+	pingHTTP("原端口", oldHTTPPort)
+	pingHTTP("新端口", newHTTPPort)
+
+	// 新端口被占用, 启动失败时恢复原配置
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer listener.Close()
+	badConfig := newConfig
+	badConfig.Comm.HTTP = []libob.ConfigCommHTTP{{Host: "127.0.0.1", Port: uint16(listener.Addr().(*net.TCPAddr).Port)}}
+	var startErr *libob.StartError
+	fmt.Println(errors.As(ob.ApplyConfig(&badConfig), &startErr))
+	pingWS()
+	pingHTTP("新端口", newHTTPPort)
+
+	// Output:
+	// WebSocket: pong <nil>
+	// <nil>
+	// WebSocket: pong <nil>
+	// HTTP 原端口 不可用
+	// HTTP 新端口 可用
+	// true
+	// WebSocket: pong <nil>
+	// HTTP 新端口 可用
+}
+
+func Example_shutdown() {
+	// 示例: 收到 SIGINT 后优雅地停止 OneBot 实例
+
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			WS: []libob.ConfigCommWS{{Host: "127.0.0.1", Port: 6700}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	<-ctx.Done()
+
+	// 最多等待 10 秒, 让正在处理的动作请求和 HTTP Webhook 推送完成, 超时后强制关闭
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := ob.Shutdown(shutdownCtx); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func Example_restart() {
+	// 示例: 运行中的 OneBot 实例不能再次启动, 停止后可以重新启动
+
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, &libob.Config{})
+	ob.Logger = libob.DiscardLogger
+	fmt.Println(ob.Start(context.Background()))
+	fmt.Println(ob.Start(context.Background()) == libob.ErrAlreadyRunning)
+	fmt.Println(ob.Shutdown(context.Background()))
+	fmt.Println(ob.Start(context.Background()))
+	fmt.Println(ob.Shutdown(context.Background()), ob.Wait())
+
+	// Output:
+	// <nil>
+	// true
+	// <nil>
+	// <nil>
+	// <nil> <nil>
+}
+
+func Example_shutdownTimeout() {
+	// 示例: 动作处理器超过关闭截止时间时, Shutdown 按时返回
+
+	// 事件接收端在 HTTP Webhook 响应中返回一个动作请求
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"action":"slow","params":{}}]`))
+	}))
+	defer receiver.Close()
+
+	config := &libob.Config{
+		Comm: libob.ConfigComm{
+			HTTPWebhook: []libob.ConfigCommHTTPWebhook{{URL: receiver.URL}},
+		},
+	}
+	ob := libob.NewOneBot("go-onebot-qq", &libob.Self{Platform: "qq", UserID: "10001"}, config)
+	ob.Logger = libob.DiscardLogger
+	handlerStarted := make(chan struct{})
+	mux := libob.NewActionMux()
+	mux.HandleFunc("slow", func(w libob.ResponseWriter, r *libob.Request) {
+		close(handlerStarted)
+		time.Sleep(200 * time.Millisecond)
+		event := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "10002")
+		ob.Push(&event) // 关闭过程中推送的事件将被丢弃, 不会阻塞
+		time.Sleep(2 * time.Second)
+	})
+	ob.Handle(mux)
+	if err := ob.Start(context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	event := libob.MakeFriendIncreaseNoticeEvent(time.Now(), "10001")
+	ob.Push(&event)
+	<-handlerStarted
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err := ob.Shutdown(ctx)
+	fmt.Println(err, time.Since(start) < time.Second)
+	fmt.Println(ob.Wait(), time.Since(start) < time.Second)
+
+	// Output:
+	// context deadline exceeded true
+	// <nil> true
+}
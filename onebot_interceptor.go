@@ -0,0 +1,121 @@
+package libonebot
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventInterceptor 在事件推送前拦截事件.
+//
+// Intercept 可以直接修改事件, 也可以返回另一个事件替换原事件, 返回 nil 则丢弃该事件.
+// self 为收到事件的机器人自身标识, 即 PushWithSelf 的参数.
+type EventInterceptor interface {
+	Intercept(event AnyEvent, self *Self) AnyEvent
+}
+
+// EventInterceptorFunc 表示一个实现 EventInterceptor 接口的函数.
+type EventInterceptorFunc func(event AnyEvent, self *Self) AnyEvent
+
+// Intercept 为 EventInterceptorFunc 实现 EventInterceptor 接口.
+func (f EventInterceptorFunc) Intercept(event AnyEvent, self *Self) AnyEvent {
+	return f(event, self)
+}
+
+// EventInterceptorStats 表示一个事件拦截器的统计数据.
+type EventInterceptorStats struct {
+	Name     string // 拦截器名称
+	Seen     uint64 // 经过该拦截器的事件数
+	Replaced uint64 // 被该拦截器替换的事件数
+	Dropped  uint64 // 被该拦截器丢弃的事件数
+}
+
+// eventDropReasoner 由需要在运行指标中使用单独丢弃原因的事件拦截器实现, 默认丢弃原因为 intercepted.
+type eventDropReasoner interface {
+	dropReason() string
+}
+
+type namedEventInterceptor struct {
+	name        string
+	interceptor EventInterceptor
+	seen        uint64
+	replaced    uint64
+	dropped     uint64
+}
+
+type eventInterceptorChain struct {
+	interceptors []*namedEventInterceptor
+	lock         *sync.RWMutex
+}
+
+func newEventInterceptorChain() *eventInterceptorChain {
+	return &eventInterceptorChain{
+		interceptors: make([]*namedEventInterceptor, 0),
+		lock:         &sync.RWMutex{},
+	}
+}
+
+// UseEventInterceptor 在事件拦截器链末尾添加一个拦截器.
+//
+// 推送事件时, 拦截器在事件补全 (时间, ID, self 等字段) 之后, 生成 alt_message 和序列化之前按添加顺序依次调用,
+// 任一拦截器丢弃事件后, 后续拦截器不再调用, 事件也不会被推送.
+//
+// 参数:
+//   name: 拦截器名称, 用于日志和统计数据
+//   interceptor: 事件拦截器
+func (ob *OneBot) UseEventInterceptor(name string, interceptor EventInterceptor) {
+	if interceptor == nil {
+		panic("事件拦截器不能为 nil")
+	}
+	ob.eventInterceptors.lock.Lock()
+	defer ob.eventInterceptors.lock.Unlock()
+	ob.eventInterceptors.interceptors = append(ob.eventInterceptors.interceptors, &namedEventInterceptor{
+		name:        name,
+		interceptor: interceptor,
+	})
+}
+
+// EventInterceptorStats 获取各事件拦截器的统计数据, 按拦截器添加顺序排列.
+func (ob *OneBot) EventInterceptorStats() []EventInterceptorStats {
+	ob.eventInterceptors.lock.RLock()
+	defer ob.eventInterceptors.lock.RUnlock()
+	stats := make([]EventInterceptorStats, len(ob.eventInterceptors.interceptors))
+	for i, ni := range ob.eventInterceptors.interceptors {
+		stats[i] = EventInterceptorStats{
+			Name:     ni.name,
+			Seen:     atomic.LoadUint64(&ni.seen),
+			Replaced: atomic.LoadUint64(&ni.replaced),
+			Dropped:  atomic.LoadUint64(&ni.dropped),
+		}
+	}
+	return stats
+}
+
+// intercept 依次调用事件拦截器, 返回最终的事件, 事件被丢弃时返回 nil 和运行指标中的丢弃原因.
+func (ob *OneBot) intercept(event AnyEvent, self *Self) (AnyEvent, string) {
+	ob.eventInterceptors.lock.RLock()
+	interceptors := ob.eventInterceptors.interceptors
+	ob.eventInterceptors.lock.RUnlock()
+
+	for _, ni := range interceptors {
+		atomic.AddUint64(&ni.seen, 1)
+		result := ni.interceptor.Intercept(event, self)
+		if result == nil {
+			atomic.AddUint64(&ni.dropped, 1)
+			ob.Logger.Infof("事件 `%v` 被拦截器 `%v` 丢弃", event.Name(), ni.name)
+			if reasoner, ok := ni.interceptor.(eventDropReasoner); ok {
+				return nil, reasoner.dropReason()
+			}
+			return nil, "intercepted"
+		}
+		if result != event {
+			atomic.AddUint64(&ni.replaced, 1)
+			if err := result.tryFixUp(self); err != nil {
+				atomic.AddUint64(&ni.dropped, 1)
+				ob.Logger.Errorf("拦截器 `%v` 返回的事件无效, 错误: %v", ni.name, err)
+				return nil, "intercepted"
+			}
+			event = result
+		}
+	}
+	return event, ""
+}
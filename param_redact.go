@@ -0,0 +1,92 @@
+// 动作参数脱敏
+
+package libonebot
+
+import (
+	"strings"
+	"sync"
+)
+
+// RedactedPlaceholder 为脱敏后的参数值.
+const RedactedPlaceholder = "[REDACTED]"
+
+// DefaultRedactedParams 为默认脱敏的参数名.
+var DefaultRedactedParams = []string{"access_token", "token", "password", "secret", "message"}
+
+// ParamRedactor 在日志等输出中隐藏敏感的动作参数.
+//
+// 参数名不区分大小写, 在嵌套的映射和数组中同样生效.
+type ParamRedactor struct {
+	keys map[string]bool
+	lock *sync.RWMutex
+}
+
+// NewParamRedactor 创建一个新的 ParamRedactor 对象.
+//
+// 参数:
+//   keys: 需要脱敏的参数名
+func NewParamRedactor(keys ...string) *ParamRedactor {
+	r := &ParamRedactor{
+		keys: make(map[string]bool),
+		lock: &sync.RWMutex{},
+	}
+	r.Add(keys...)
+	return r
+}
+
+// Add 添加需要脱敏的参数名.
+func (r *ParamRedactor) Add(keys ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+}
+
+// Remove 移除需要脱敏的参数名.
+func (r *ParamRedactor) Remove(keys ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, key := range keys {
+		delete(r.keys, strings.ToLower(key))
+	}
+}
+
+// Redact 返回脱敏后的参数副本, 原参数不受影响. r 为 nil 时不做脱敏, 但仍返回副本.
+func (r *ParamRedactor) Redact(params map[string]interface{}) map[string]interface{} {
+	if r != nil {
+		r.lock.RLock()
+		defer r.lock.RUnlock()
+	}
+	return r.redactMap(params)
+}
+
+func (r *ParamRedactor) redactMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r != nil && r.keys[strings.ToLower(k)] {
+			result[k] = RedactedPlaceholder
+		} else {
+			result[k] = r.redactValue(v)
+		}
+	}
+	return result
+}
+
+func (r *ParamRedactor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return r.redactMap(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, vv := range v {
+			result[i] = r.redactValue(vv)
+		}
+		return result
+	default:
+		return v
+	}
+}
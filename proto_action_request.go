@@ -4,6 +4,7 @@
 package libonebot
 
 import (
+	"context"
 	"errors"
 
 	"github.com/botuniverse/go-libonebot/utils"
@@ -13,17 +14,22 @@ import (
 )
 
 const (
-	CommMethodNone        = 0 // 无通信方式 (OneBot 实现内部构造的请求)
-	CommMethodHTTP        = 1 // HTTP 通信方式
-	CommMethodHTTPWebhook = 2 // HTTP Webhook 通信方式
-	CommMethodWS          = 3 // WebSocket 通信方式
-	CommMethodWSReverse   = 4 // 反向 WebSocket 通信方式
+	CommMethodNone         = 0 // 无通信方式 (OneBot 实现内部构造的请求)
+	CommMethodHTTP         = 1 // HTTP 通信方式
+	CommMethodHTTPWebhook  = 2 // HTTP Webhook 通信方式
+	CommMethodWS           = 3 // WebSocket 通信方式
+	CommMethodWSReverse    = 4 // 反向 WebSocket 通信方式
+	CommMethodV11HTTP      = 5 // OneBot 11 HTTP 通信方式
+	CommMethodV11WS        = 6 // OneBot 11 正向 WebSocket 通信方式
+	CommMethodV11WSReverse = 7 // OneBot 11 反向 WebSocket 通信方式
 )
 
 // RequestCommMethod 表示接收动作请求的通信方式.
 type RequestComm struct {
-	Method int         // 通信方式
-	Config interface{} // 通信方式配置
+	Method     int         // 通信方式
+	Config     interface{} // 通信方式配置
+	RemoteAddr string      // 请求方的网络地址, 无法获取时为空字符串
+	Principal  string      // 通过鉴权的请求方身份, 主动连接的通信方式 (HTTP Webhook 和反向 WebSocket) 为去掉用户信息和查询参数的连接 URL
 }
 
 // Request 表示一个动作请求.
@@ -33,6 +39,30 @@ type Request struct {
 	Params EasierMap   // 动作参数
 	Echo   string      // 动作请求的 echo 字段, 用户未指定时为空字符串
 	Self   *Self       // 机器人自身标识, 用户未指定时为 nil
+
+	segments *SegmentRegistry // 处理请求的 OneBot 实例的消息段类型注册表
+	ctx      context.Context  // 动作请求的 context, 带有调用链路上下文
+}
+
+// Context 获取动作请求的 context.
+//
+// 动作处理器中可以通过 OneBot.Tracer.Start(r.Context(), ...) 创建子 Span, 以追踪调用机器人平台 API 等操作.
+// 调用链路上下文来自 HTTP 请求头或动作请求中的 traceparent 字段.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// ValidateMessage 检查动作请求中的消息.
+//
+// 若请求由 OneBot 实例处理, 则根据其消息段类型注册表 (OneBot.Segments) 检查, 否则只按 OneBot 标准检查.
+func (r *Request) ValidateMessage(m Message) error {
+	if r.segments != nil {
+		return r.segments.Validate(m)
+	}
+	return m.Validate()
 }
 
 func parseRequestFromMap(m map[string]interface{}, reqComm RequestComm) (r Request, err error) {
@@ -74,6 +104,9 @@ func parseRequestFromMap(m map[string]interface{}, reqComm RequestComm) (r Reque
 		Echo:   echo,
 		Self:   self,
 	}
+	if traceParent, err := em.GetString("traceparent"); err == nil && traceParent != "" {
+		r.ctx = contextWithTraceParent(context.Background(), traceParent)
+	}
 	return r, nil
 }
 
@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"reflect"
+	"strings"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -106,3 +108,99 @@ func (w ResponseWriter) WriteFailed(retCode int, err error) {
 	w.resp.RetCode = retCode
 	w.resp.Message = err.Error()
 }
+
+// WriteError 向 Response 写入失败状态, 返回码由 RetCodeFromError 根据错误确定.
+func (w ResponseWriter) WriteError(err error) {
+	w.WriteFailed(RetCodeFromError(err), err)
+}
+
+// ExtendedFields 表示动作响应数据中的扩展字段, 字段名应带有平台前缀 (如 `qq.avatar`).
+//
+// 标准动作响应数据类型均包含一个 Extended 字段, 其中的扩展字段会在编码时与标准字段平铺在同一层级.
+type ExtendedFields map[string]interface{}
+
+// parseJSONTag 解析结构体字段的 json tag, 返回字段名和是否带有 omitempty 选项.
+func parseJSONTag(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	name, opts := tag, ""
+	if idx := strings.Index(tag, ","); idx >= 0 {
+		name, opts = tag[:idx], tag[idx+1:]
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// responseDataToMap 将包含 json tag 的响应数据结构体转换为 map, 并合并扩展字段.
+func responseDataToMap(data interface{}, extended ExtendedFields) map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range extended {
+		m[k] = v
+	}
+	v := reflect.ValueOf(data)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name, omitEmpty := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		m[name] = fv.Interface()
+	}
+	return m
+}
+
+func marshalResponseDataJSON(data interface{}, extended ExtendedFields) ([]byte, error) {
+	return json.Marshal(responseDataToMap(data, extended))
+}
+
+func marshalResponseDataMsgpack(data interface{}, extended ExtendedFields) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	err := enc.Encode(responseDataToMap(data, extended))
+	return buf.Bytes(), err
+}
+
+// ActionError 表示一个带有返回码的动作执行错误.
+//
+// 动作处理器可以返回 ActionError 来指定失败响应的返回码, 参考 ResponseWriter.WriteError.
+type ActionError struct {
+	RetCode int   // 返回码
+	Err     error // 错误信息
+}
+
+// NewActionError 构造一个 ActionError 对象.
+func NewActionError(retCode int, err error) *ActionError {
+	return &ActionError{
+		RetCode: retCode,
+		Err:     err,
+	}
+}
+
+// Error 为 ActionError 实现 error 接口.
+func (e *ActionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回 ActionError 包装的错误.
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// RetCodeFromError 获取错误对应的返回码, 对于不包含 ActionError 的错误返回 RetCodeInternalHandlerError.
+func RetCodeFromError(err error) int {
+	var actionErr *ActionError
+	if errors.As(err, &actionErr) {
+		return actionErr.RetCode
+	}
+	return RetCodeInternalHandlerError
+}
@@ -27,14 +27,18 @@ type Event struct {
 	DetailType string  `json:"detail_type"`    // 事件详细类型
 	SubType    string  `json:"sub_type"`       // 事件子类型 (详细类型的下一级类型), 可为空
 	Self       *Self   `json:"self,omitempty"` // 机器人自身标识, 仅用于非元事件, 无需在构造时传入
+
+	generatedID string // 构造时自动生成的事件 ID, 用于判断 ID 是否被替换为平台原生 ID
 }
 
 func makeEvent(time time.Time, type_ string, detailType string) Event {
+	id := uuid.New().String()
 	return Event{
-		ID:         uuid.New().String(),
-		Time:       float64(time.UnixMicro()) / 1e6,
-		Type:       type_,
-		DetailType: detailType,
+		ID:          id,
+		Time:        float64(time.UnixMicro()) / 1e6,
+		Type:        type_,
+		DetailType:  detailType,
+		generatedID: id,
 	}
 }
 
@@ -97,7 +101,7 @@ type MessageEvent struct {
 	Event
 	MessageID  string  `json:"message_id"`  // 消息 ID
 	Message    Message `json:"message"`     // 消息内容
-	AltMessage string  `json:"alt_message"` // 消息内容的替代表示, 可为空
+	AltMessage string  `json:"alt_message"` // 消息内容的替代表示, 为空时将在推送时自动生成
 }
 
 // MakeMessageEvent 构造一个消息事件.
@@ -0,0 +1,103 @@
+// 优雅关闭
+
+package libonebot
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// wsCloseTimeout 是发送 WebSocket 关闭帧后等待对方关闭连接的最长时间.
+const wsCloseTimeout = 3 * time.Second
+
+// wsCloseReason 是 OneBot 实例关闭时发送的 WebSocket 关闭帧中的原因.
+const wsCloseReason = "OneBot 正在关闭"
+
+// inflightTracker 跟踪正在处理的请求, 停止接受新请求后可等待其完成.
+type inflightTracker struct {
+	closing bool
+	lock    *sync.Mutex
+	wg      *sync.WaitGroup
+}
+
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{
+		lock: &sync.Mutex{},
+		wg:   &sync.WaitGroup{},
+	}
+}
+
+// begin 开始处理一个请求, 已停止接受新请求时返回 false, 否则处理完成后必须调用 done.
+func (t *inflightTracker) begin() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closing {
+		return false
+	}
+	t.wg.Add(1)
+	return true
+}
+
+func (t *inflightTracker) done() {
+	t.wg.Done()
+}
+
+// drain 停止接受新请求并等待正在处理的请求完成, ctx 先被取消时返回 false.
+func (t *inflightTracker) drain(ctx context.Context) bool {
+	t.lock.Lock()
+	t.closing = true
+	t.lock.Unlock()
+	return waitGroupContext(t.wg, ctx)
+}
+
+// waitGroupContext 等待 wg 完成, ctx 先被取消时返回 false.
+func waitGroupContext(wg *sync.WaitGroup, ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// discardEvents 在后台丢弃 ch 中的事件, 直到 ch 被 closeEventListenChan 关闭.
+//
+// 通信方式停止推送事件后仍需调用, 否则正在处理的动作请求中推送事件时将阻塞在 ch 上, 使等待其完成的关闭过程无法继续.
+func discardEvents(ch <-chan marshaledEvent) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
+
+// drainContext 返回通信方式停止时等待正在处理的请求所使用的 context.
+//
+// ctx 为通信方式的运行 context, 其所属组件被 ApplyConfig 单独停止时使用 ApplyConfig 指定的 context,
+// 否则使用 Shutdown 指定的 context, 都未指定时没有截止时间.
+func (ob *OneBot) drainContext(ctx context.Context) context.Context {
+	if h, ok := ctx.Value(commHandleKey{}).(*commHandle); ok && h.drainCtx != nil {
+		return h.drainCtx
+	}
+	ob.runLock.Lock()
+	defer ob.runLock.Unlock()
+	if ob.drainCtx == nil {
+		return context.Background()
+	}
+	return ob.drainCtx
+}
+
+// shutdownServer 关闭 HTTP 服务器, 停止接受新连接并等待正在处理的请求完成, 超过 drainContext 的截止时间后强制关闭.
+func (ob *OneBot) shutdownServer(ctx context.Context, server *http.Server) error {
+	if err := server.Shutdown(ob.drainContext(ctx)); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+// 链路追踪
+
+package libonebot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TraceID 表示一条调用链路的 ID.
+type TraceID [16]byte
+
+// String 返回 TraceID 的十六进制表示.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 判断 TraceID 是否有效 (不全为 0).
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID 表示一个 Span 的 ID.
+type SpanID [8]byte
+
+// String 返回 SpanID 的十六进制表示.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 判断 SpanID 是否有效 (不全为 0).
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext 表示在进程间传递的调用链路上下文, 对应 W3C Trace Context 的 traceparent.
+type SpanContext struct {
+	TraceID TraceID // 调用链路 ID
+	SpanID  SpanID  // Span ID
+	Sampled bool    // 是否记录该调用链路
+}
+
+// IsValid 判断 SpanContext 是否有效.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent 返回 SpanContext 的 traceparent 表示, 可用于 HTTP 请求头或动作请求的 traceparent 字段.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent 解析 traceparent 字符串.
+func ParseTraceParent(s string) (SpanContext, error) {
+	// version-traceid-spanid-flags, future versions may append more fields
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') {
+		return SpanContext{}, errors.New("traceparent 格式错误")
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, errors.New("traceparent 格式错误")
+	}
+	version, err := decodeLowerHex(s[0:2], 1)
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(s) != 55) {
+		return SpanContext{}, errors.New("traceparent 版本无效")
+	}
+	sc := SpanContext{}
+	traceID, err := decodeLowerHex(s[3:35], 16)
+	if err != nil {
+		return SpanContext{}, errors.New("traceparent 中的 trace-id 无效")
+	}
+	copy(sc.TraceID[:], traceID)
+	spanID, err := decodeLowerHex(s[36:52], 8)
+	if err != nil {
+		return SpanContext{}, errors.New("traceparent 中的 parent-id 无效")
+	}
+	copy(sc.SpanID[:], spanID)
+	flags, err := decodeLowerHex(s[53:55], 1)
+	if err != nil {
+		return SpanContext{}, errors.New("traceparent 中的 trace-flags 无效")
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("traceparent 中的 ID 全为 0")
+	}
+	return sc, nil
+}
+
+func decodeLowerHex(s string, n int) ([]byte, error) {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return nil, fmt.Errorf("`%v` 不是小写十六进制数", s)
+		}
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != n {
+		return nil, fmt.Errorf("`%v` 长度错误", s)
+	}
+	return b, nil
+}
+
+type spanContextKey struct{}
+type spanKey struct{}
+
+// ContextWithSpanContext 返回带有指定调用链路上下文的 context, 之后在该 context 上创建的 Span 将以其为父 Span.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext 获取 context 中的调用链路上下文, 不存在时返回无效的 SpanContext.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// SpanFromContext 获取 context 中当前的 Span, 不存在时返回 nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// contextWithTraceParent 解析 traceparent 字符串, 若有效则返回带有对应调用链路上下文的 context, 否则原样返回 ctx.
+func contextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	sc, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// injectTraceParent 将 context 中的调用链路上下文写入 HTTP 请求头.
+func injectTraceParent(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set("traceparent", sc.TraceParent())
+	}
+}
+
+// SpanData 表示一个已结束的 Span 的数据.
+type SpanData struct {
+	Name         string                 // Span 名称
+	SpanContext  SpanContext            // Span 的调用链路上下文
+	ParentSpanID SpanID                 // 父 Span ID, 没有父 Span 时全为 0
+	StartTime    time.Time              // 开始时间
+	EndTime      time.Time              // 结束时间
+	Attributes   map[string]interface{} // 属性
+	Error        string                 // 错误信息, 为空表示成功
+}
+
+// Duration 返回 Span 的持续时间.
+func (d SpanData) Duration() time.Duration {
+	return d.EndTime.Sub(d.StartTime)
+}
+
+// Span 表示调用链路中的一段操作.
+//
+// 所有方法都可以在 nil 上调用, 此时不做任何事, 因此不记录调用链路时 Tracer.Start 返回 nil.
+type Span struct {
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+	lock   *sync.Mutex
+}
+
+// SpanContext 获取 Span 的调用链路上下文.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttribute 设置 Span 的属性.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+// SetError 将 Span 标记为失败.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.ended {
+		s.data.Error = err.Error()
+	}
+}
+
+// End 结束 Span 并将其交给 SpanExporter 导出, 多次调用只有第一次有效.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.lock.Unlock()
+	s.tracer.exporter.ExportSpan(data)
+}
+
+// SpanExporter 导出已结束的 Span, 例如发送到 OpenTelemetry Collector.
+//
+// ExportSpan 在结束 Span 的 goroutine 中同步调用, 耗时的导出操作应自行缓冲.
+type SpanExporter interface {
+	ExportSpan(data SpanData)
+}
+
+// Tracer 创建 Span 并通过 SpanExporter 导出.
+type Tracer struct {
+	exporter SpanExporter
+}
+
+// NewTracer 创建一个新的 Tracer 对象.
+//
+// 参数:
+//   exporter: Span 导出器, 为 nil 时不记录 Span, 但仍传递调用链路上下文
+func NewTracer(exporter SpanExporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start 在 ctx 中的 Span 或调用链路上下文之下创建一个新的 Span, 并返回带有该 Span 的 context.
+//
+// Tracer 没有 SpanExporter 或上游指定不记录该调用链路时, 返回原 ctx 和 nil.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil || t.exporter == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() && !parent.Sampled {
+		return ctx, nil
+	}
+	sc := SpanContext{Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+	} else {
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:         name,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			StartTime:    time.Now(),
+			Attributes:   make(map[string]interface{}),
+		},
+		lock: &sync.Mutex{},
+	}
+	ctx = ContextWithSpanContext(ctx, sc)
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	return ctx, span
+}
+
+// InMemorySpanExporter 将 Span 保存在内存中, 主要用于测试.
+type InMemorySpanExporter struct {
+	spans []SpanData
+	lock  *sync.Mutex
+}
+
+// NewInMemorySpanExporter 创建一个新的 InMemorySpanExporter 对象.
+func NewInMemorySpanExporter() *InMemorySpanExporter {
+	return &InMemorySpanExporter{
+		spans: make([]SpanData, 0),
+		lock:  &sync.Mutex{},
+	}
+}
+
+// ExportSpan 为 InMemorySpanExporter 实现 SpanExporter 接口.
+func (e *InMemorySpanExporter) ExportSpan(data SpanData) {
+	e.lock.Lock()
+	e.spans = append(e.spans, data)
+	e.lock.Unlock()
+}
+
+// Spans 获取已导出的 Span, 按结束顺序排列.
+func (e *InMemorySpanExporter) Spans() []SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]SpanData{}, e.spans...)
+}
+
+// Reset 清空已导出的 Span.
+func (e *InMemorySpanExporter) Reset() {
+	e.lock.Lock()
+	e.spans = e.spans[:0]
+	e.lock.Unlock()
+}